@@ -20,46 +20,66 @@ import (
 //
 // app [cmd] [-flag] [-flag] [opt] [-flag] [opt] [-flag] ...
 //
+// Further levels of sub-commands may be registered beneath a command by
+// way of its Node.
+//
 // Any errors are accumulated into the Config.errs value and dealt with
 // when Compose, if ignored then returned when a value from the command
 // set is accessed.
 func (c *Config) Command(cmd, usage string) CMD {
 	const fname = "Config.Command"
+	set, err := addCommand(c, 0, cmd, usage)
+	if err != nil {
+		c.errs = fmt.Errorf("%s: %w", fname, err)
+		return CMD{}
+	}
+
+	if v1() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return set
+}
+
+// addCommand registers a command beneath the command at the index
+// parent of the Config's commands, the parent is ignored for the first
+// command, the default set, which is the root of the command tree.
+func addCommand(c *Config, parent int, cmd, usage string) (CMD, error) {
+	const fname = "addCommand"
 
 	// If not OK store the error and leave.
 	if err := cmdPreconditions(c, cmd, usage); err != nil {
-		c.errs = fmt.Errorf("%s: %w", fname, err)
-		return 0
+		return CMD{}, fmt.Errorf("%s: %w", fname, err)
 	}
 
 	// First run
-	if c.position == 0 {
-		c.position = 1
+	if len(c.commands) == 0 {
 		c.header = cmd
-		// As we know the max length we can make the backing array now.
-		c.commands = make([]command, 0, 64)
+		parent = -1
 	}
 
-	// We do not need to check for duplicates on either the default
-	// command set nor the first sub command.
-	if c.position > 2 {
-		if err := checkDuplicate(c, cmd); err != nil {
-			c.errs = fmt.Errorf("%s: %w", fname, err)
-			return 0
+	// We do not need to check for duplicates on the default command
+	// set, duplicate names are permitted within differing branches
+	// of the command tree.
+	if parent >= 0 {
+		if err := checkDuplicate(c, parent, cmd); err != nil {
+			return CMD{}, fmt.Errorf("%s: %w", fname, err)
 		}
 	}
 
 	// Set the new command.
-	m := command{flag: c.position, cmd: cmd, usage: usage}
+	set := cmdBit(len(c.commands))
+	m := command{flag: set, cmd: cmd, usage: usage, parent: parent}
+	if parent >= 0 {
+		m.depth = c.commands[parent].depth + 1
+	}
 	c.commands = append(c.commands, m)
-	set := c.position
-	c.position = c.position << 1
 
-	if v1() {
+	if v2() {
 		log.Printf("%s: completed\n", fname)
 	}
 
-	return set
+	return set, nil
 }
 
 func cmdPreconditions(c *Config, cmd, usage string) error {
@@ -69,11 +89,6 @@ func cmdPreconditions(c *Config, cmd, usage string) error {
 		const event = "empty cmd string not permitted"
 		return fmt.Errorf("%s: %s: %w", fname, event, errConfig)
 	}
-	if c.position >= limit {
-		const event = "64 sub command limit reached"
-		return fmt.Errorf("%s|%s: %s: %w",
-			c.errs, fname, event, errConfig)
-	}
 
 	if v2() {
 		log.Printf("%s: completed\n", fname)
@@ -83,11 +98,11 @@ func cmdPreconditions(c *Config, cmd, usage string) error {
 }
 
 // checkDuplicate returns an error if the given command name is already
-// in use.
-func checkDuplicate(c *Config, cmd string) error {
+// in use by a sibling, a command with the same parent.
+func checkDuplicate(c *Config, parent int, cmd string) error {
 	const fname = "checkDuplicate"
 	for _, c := range c.commands {
-		if strings.Compare(c.cmd, cmd) == 0 {
+		if c.parent == parent && strings.Compare(c.cmd, cmd) == 0 {
 			const event = "duplicate command"
 			return fmt.Errorf("%s: %s: %s",
 				fname, cmd, event)
@@ -145,6 +160,22 @@ type command struct {
 	// The usage output for the command displayed when -h is called or
 	// an error raised upon parsing the flagset.
 	usage string
+	// parent is the index of the parent command within the Config's
+	// commands, -1 for the default set which is the root of the
+	// command tree.
+	parent int
+	// depth is the number of command tokens required on the command
+	// line to call the command, zero for the default set.
+	depth int
+	// summary is the one line description of the command shown in
+	// the COMMANDS section of its parent's usage output.
+	summary string
+	// group is the heading under which the command is listed in the
+	// COMMANDS section, empty for none.
+	group string
+	// run is the handler that is called by Execute when the command
+	// is running.
+	run Handler
 	// seen makes certain that no flag duplicates exist within the
 	// set.
 	seen flags
@@ -153,44 +184,316 @@ type command struct {
 	options options
 }
 
-// CMD is a bitfield that records which Options have been registered
-// with a command set.
-type CMD uint64
+// CMD is a set of commands, a bitfield that records which Options have
+// been registered with which command sets. Each call to Command returns
+// a token holding its command alone, tokens are combined into a set with
+// either Cmds or CMD.Or, there being no limit upon the number of
+// commands. CMD values are comparable, such that the token returned by
+// Compose may be used within a switch statement.
+//
+//	Commands: conf.Cmds(def, one, two),
+type CMD struct {
+	// bits contains the bitfield, it is stored as a string such that
+	// CMD remains comparable, trailing zero bytes are never stored
+	// so that equal sets have equal representations.
+	bits string
+}
 
-func (c CMD) String() string {
-	var count int
-	if c < 0 {
-		panic("illegal value")
+// cmdBit returns the token for the command at index i.
+func cmdBit(i int) CMD {
+	b := make([]byte, i/8+1)
+	b[i/8] = 1 << (i % 8)
+	return CMD{bits: string(b)}
+}
+
+// Cmds returns the set containing all of the given tokens.
+func Cmds(tokens ...CMD) CMD {
+	return CMD{}.Or(tokens...)
+}
+
+// Or returns the set containing the commands of c along with those of
+// all the given tokens.
+func (c CMD) Or(tokens ...CMD) CMD {
+	b := []byte(c.bits)
+	for _, t := range tokens {
+		for len(b) < len(t.bits) {
+			b = append(b, 0)
+		}
+		for i := 0; i < len(t.bits); i++ {
+			b[i] |= t.bits[i]
+		}
 	}
-	for c > 0 {
-		c = c >> 1
-		count++
+	return CMD{bits: string(b)}
+}
+
+// Has returns true if any of the commands in the given set are also in
+// the set c.
+func (c CMD) Has(set CMD) bool {
+	for i := 0; i < len(c.bits) && i < len(set.bits); i++ {
+		if c.bits[i]&set.bits[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsZero returns true if the set contains no commands.
+func (c CMD) IsZero() bool {
+	return c.bits == ""
+}
+
+// indices returns the index of every command in the set, in order.
+func (c CMD) indices() []int {
+	var out []int
+	for i := 0; i < len(c.bits)*8; i++ {
+		if c.bits[i/8]&(1<<(i%8)) != 0 {
+			out = append(out, i)
+		}
 	}
-	return strconv.Itoa(count)
+	return out
+}
+
+// String returns the position of each command in the set, counting
+// from one, separated by the | character.
+func (c CMD) String() string {
+	var s []string
+	for _, i := range c.indices() {
+		s = append(s, strconv.Itoa(i+1))
+	}
+	return strings.Join(s, "|")
 }
 
 // isInSet returns true if a command token exists within the
 // configured set of commands, false if it does not.
-func isInSet(c *Config, bitfield CMD) bool {
-	fullset := (c.position << 1) - 1
-	if bitfield == (fullset)&bitfield {
-		return true
+func isInSet(c *Config, set CMD) bool {
+	for _, i := range set.indices() {
+		if i >= len(c.commands) {
+			return false
+		}
 	}
-	return false
+	return true
+}
+
+// findCommand returns the index of the named command amongst the
+// children of the command at the index parent, returning an error if
+// the named command does not exist.
+func findCommand(c *Config, parent int, name string) (int, error) {
+	const fname = "findCommand"
+	for i, m := range c.commands {
+		if m.parent == parent && strings.Compare(name, m.cmd) == 0 {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%s: %s: %w", fname, name, ErrUnknownCMD)
+}
+
+// cmdPath returns the names of the command and all of its ancestors,
+// excluding the default set, joined by sep; The path of the default
+// set is an empty string.
+func cmdPath(c *Config, m *command, sep string) string {
+	var names []string
+	for m.parent >= 0 {
+		names = append([]string{m.cmd}, names...)
+		m = &c.commands[m.parent]
+	}
+	return strings.Join(names, sep)
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Node
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// Node is a handle upon a registered command, through which further
+// sub-commands may be registered beneath it, creating a tree of
+// commands.
+//
+//	remote := c.Node(c.Command("remote", helpRemote))
+//	add := remote.Command("add", helpRemoteAdd)
+//	rm := remote.Command("rm", helpRemoteRm)
+//
+//	app remote add -name origin
+type Node struct {
+	c     *Config
+	token CMD
+}
+
+// Node returns a handle upon the command with the given token, as
+// returned by Command.
+func (c *Config) Node(token CMD) Node {
+	return Node{c: c, token: token}
+}
+
+// Command registers a sub-command beneath the node's command, returning
+// a handle upon the new command. Any errors are accumulated into the
+// Config in the same manner as Config.Command.
+func (n Node) Command(cmd, usage string) Node {
+	const fname = "Node.Command"
+	c := n.c
+	parent, err := commandIndex(c, n.token)
+	if err != nil {
+		c.errs = fmt.Errorf("%s: %w", fname, err)
+		return Node{c: c}
+	}
+	set, err := addCommand(c, parent, cmd, usage)
+	if err != nil {
+		c.errs = fmt.Errorf("%s: %w", fname, err)
+		return Node{c: c}
+	}
+
+	if v1() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return Node{c: c, token: set}
+}
+
+// Summary sets the one line description of the node's command, shown
+// beside its name in the COMMANDS section of the usage output of its
+// parent command; Without one, the first line of the command's usage is
+// shown.
+//
+//	c.Node(one).Summary("one does all things that one should do.")
+func (n Node) Summary(summary string) Node {
+	const fname = "Node.Summary"
+	i, err := commandIndex(n.c, n.token)
+	if err != nil {
+		n.c.errs = fmt.Errorf("%s: %w", fname, err)
+		return n
+	}
+	n.c.commands[i].summary = summary
+	return n
+}
+
+// Group sets the heading under which the node's command is listed in
+// the COMMANDS section of the usage output of its parent command,
+// commands without a group being listed first.
+//
+//	c.Node(add).Summary("add a remote").Group("remote")
+func (n Node) Group(group string) Node {
+	const fname = "Node.Group"
+	i, err := commandIndex(n.c, n.token)
+	if err != nil {
+		n.c.errs = fmt.Errorf("%s: %w", fname, err)
+		return n
+	}
+	n.c.commands[i].group = group
+	return n
 }
 
-// setCommand sets the requested command set into the Config struct as
-// its current running state, returning an error if the named command
-// set does not exist.
-func setCommand(c *Config, name string) (set CMD, err error) {
-	const fname = "setCommand"
+// Token returns the token of the node's command.
+func (n Node) Token() CMD {
+	return n.token
+}
+
+// Subtree returns a token for the node's command along with all of the
+// commands that have been registered beneath it, for use when an
+// option is to be made available throughout the subtree; It should be
+// called once all of the sub-commands have been registered.
+func (n Node) Subtree() CMD {
+	c := n.c
+	i, err := commandIndex(c, n.token)
+	if err != nil {
+		return CMD{}
+	}
+	set := n.token
+	for j := range c.commands {
+		for p := c.commands[j].parent; p >= 0; p = c.commands[p].parent {
+			if p == i {
+				set = set.Or(c.commands[j].flag)
+				break
+			}
+		}
+	}
+	return set
+}
+
+// commandIndex returns the index of the command with the given token.
+func commandIndex(c *Config, token CMD) (int, error) {
+	const fname = "commandIndex"
 	for i, m := range c.commands {
-		if strings.Compare(name, m.cmd) == 0 {
-			c.set = &c.commands[i]
-			set = c.set.flag
-			return
+		if m.flag == token {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%s: %s: %w", fname, token, ErrUnknownCMD)
+}
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Command listing
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// children returns the indices of the commands registered directly
+// beneath the command at index i, in order of registration.
+func children(c *Config, i int) []int {
+	var out []int
+	for j, m := range c.commands {
+		if m.parent == i && j != i {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
+// cmdSummary returns the summary of the command, else the first line of
+// its usage that is not blank.
+func cmdSummary(m *command) string {
+	if m.summary != "" {
+		return m.summary
+	}
+	for _, l := range strings.Split(m.usage, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+// commandsUsage returns the usage section listing the sub-commands of
+// the running command along with their summaries, those without a group
+// first and then each group under its own heading in order of first
+// appearance; An empty string is returned if there are no sub-commands.
+func commandsUsage(c *Config) string {
+	i, err := commandIndex(c, c.set.flag)
+	if err != nil {
+		return ""
+	}
+	cmds := children(c, i)
+	if len(cmds) == 0 {
+		return ""
+	}
+	width := 6
+	groups := []string{""}
+	for _, j := range cmds {
+		m := c.commands[j]
+		if len(m.cmd) > width {
+			width = len(m.cmd)
+		}
+		found := false
+		for _, g := range groups {
+			if g == m.group {
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, m.group)
+		}
+	}
+	var b strings.Builder
+	b.WriteString("\n\nCOMMANDS\n")
+	for _, g := range groups {
+		if g != "" {
+			fmt.Fprintf(&b, "\n        %s\n", g)
+		}
+		for _, j := range cmds {
+			m := c.commands[j]
+			if m.group != g {
+				continue
+			}
+			line := fmt.Sprintf("        %-*s  %s", width, m.cmd,
+				cmdSummary(&m))
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
 		}
 	}
-	err = fmt.Errorf("%s: %w", fname, ErrUnknownCMD)
-	return
+	return b.String()
 }
@@ -1,8 +1,10 @@
 package conf
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -20,7 +22,7 @@ func TestCommandGetCmd(t *testing.T) {
 			Commands: cmd,
 		},
 	}
-	_, err := c.Compose(opts...)
+	_, err := c.ComposeArgs(nil, opts...)
 	if err != nil {
 		t.Errorf("%s: should not raise an error: %s",
 			fname, err)
@@ -55,27 +57,66 @@ func TestCommandDuplicateKeys(t *testing.T) {
 			Commands: m2,
 		},
 	}
-	_, err := config.Compose(opts...)
+	_, err := config.ComposeArgs(nil, opts...)
 	if err != nil {
 		t.Errorf("%s: should not raise an error: %s",
 			fname, err)
 	}
 }
 
-func TestCommandTooMany(t *testing.T) {
-	const fname = "TestCommandTooMany"
+func TestCommandNoLimit(t *testing.T) {
+	const fname = "TestCommandNoLimit"
 	config := Config{}
-	_ = config.Command("one", "like this")
-	names := make([]string, 65)
-	for i := 0; i <= 64; i++ {
-		names[i] = fmt.Sprint(i + '0')
+	def := config.Command("one", "like this")
+	tokens := make([]CMD, 100)
+	for i := range tokens {
+		tokens[i] = config.Command(fmt.Sprint("cmd", i), "")
 	}
-	for i := 0; i <= 64; i++ {
-		_ = config.Command(names[i], "")
+	var opts = []Option{
+		{
+			Type:     Int,
+			Flag:     "a",
+			Usage:    "like this",
+			Default:  1,
+			Commands: Cmds(def, tokens[0], tokens[64], tokens[99]),
+		},
 	}
-	_, err := config.Compose()
-	if !errors.Is(err, errConfig) {
-		t.Errorf("%s: %s", fname, err)
+	cmd, err := config.ComposeArgs([]string{"cmd99", "-a", "3"}, opts...)
+	if err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if cmd != tokens[99] {
+		t.Errorf("%s: received %s expected %s", fname, cmd, tokens[99])
+	}
+	if cmd.String() != "101" {
+		t.Errorf("%s: received %s expected 101", fname, cmd)
+	}
+	if i, _ := config.ValueInt("a"); i != 3 {
+		t.Errorf("%s: received %d expected 3", fname, i)
+	}
+	if !config.IsSet(Cmds(tokens[64], tokens[99])) || config.IsSet(tokens[64]) {
+		t.Errorf("%s: IsSet failed", fname)
+	}
+	if len(config.commands[65].options) != 1 || len(config.commands[66].options) != 0 {
+		t.Errorf("%s: option not loaded in the correct commands", fname)
+	}
+}
+
+func TestCommandSet(t *testing.T) {
+	const fname = "TestCommandSet"
+	a, b, c := cmdBit(0), cmdBit(9), cmdBit(70)
+	set := Cmds(a, c)
+	if set != a.Or(c) || set != c.Or(a, CMD{}) {
+		t.Errorf("%s: equal sets are not equal", fname)
+	}
+	if !set.Has(a) || !set.Has(Cmds(b, c)) || set.Has(b) || set.Has(CMD{}) {
+		t.Errorf("%s: Has failed", fname)
+	}
+	if set.String() != "1|71" {
+		t.Errorf("%s: received %q expected \"1|71\"", fname, set)
+	}
+	if !(CMD{}).IsZero() || set.IsZero() {
+		t.Errorf("%s: IsZero failed", fname)
 	}
 }
 
@@ -83,7 +124,7 @@ func TestCommandNotThere(t *testing.T) {
 	const fname = "TestCommandNotThere"
 	config := Config{}
 	_ = config.Command("", "")
-	m := CMD(2)
+	m := cmdBit(1)
 	var opts = []Option{
 		{
 			Type:     Int,
@@ -93,7 +134,7 @@ func TestCommandNotThere(t *testing.T) {
 			Commands: m,
 		},
 	}
-	_, err := config.Compose(opts...)
+	_, err := config.ComposeArgs(nil, opts...)
 	if !errors.Is(err, errConfig) {
 		t.Errorf("%s: %s", fname, err)
 	}
@@ -114,7 +155,7 @@ func TestCommandTokens(t *testing.T) {
 			Commands: cmd1,
 		},
 	}
-	_, err := c.Compose(opts...)
+	_, err := c.ComposeArgs(nil, opts...)
 	if err != nil {
 		t.Errorf("%s: %s", fname, err)
 	}
@@ -130,16 +171,163 @@ func TestCommandTokens(t *testing.T) {
 	if !v {
 		t.Errorf("%s: received false expected true", fname)
 	}
-	v = isInSet(c, cmd1|cmd3)
+	v = isInSet(c, Cmds(cmd1, cmd3))
 	if !v {
 		t.Errorf("%s: received false expected true", fname)
 	}
-	v = isInSet(c, cmd1|cmd2|cmd3)
+	v = isInSet(c, cmd1.Or(cmd2, cmd3))
 	if !v {
 		t.Errorf("%s: received false expected true", fname)
 	}
-	v = isInSet(c, c.position)
-	if !v {
+	v = isInSet(c, cmdBit(len(c.commands)))
+	if v {
+		t.Errorf("%s: received true expected false", fname)
+	}
+}
+
+func TestCommandTree(t *testing.T) {
+	const fname = "TestCommandTree"
+	compose := func(args ...string) (*Config, []CMD, CMD) {
+		config, cmd := testConfig("remote", "remote add", "remote rm")
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 1, Commands: cmd[0]},
+			{Type: String, Flag: "name", Default: "", Commands: cmd[2]},
+			{Type: Bool, Flag: "v", Default: false,
+				Commands: config.Node(cmd[1]).Subtree()},
+		}
+		set, err := config.ComposeArgs(args, opts...)
+		if err != nil {
+			t.Fatalf("%s: %s", fname, err)
+		}
+		return config, cmd, set
+	}
+	config, cmd, set := compose("remote", "add", "-name", "origin", "-v", "url")
+	if set != cmd[2] {
+		t.Errorf("%s: received %s expected %s", fname, set, cmd[2])
+	}
+	if s, _ := config.ValueString("name"); s != "origin" {
+		t.Errorf("%s: received %q expected \"origin\"", fname, s)
+	}
+	if b, _ := config.ValueBool("v"); !b {
 		t.Errorf("%s: received false expected true", fname)
 	}
+	if config.NArg() != 1 || config.Args()[0] != "url" {
+		t.Errorf("%s: received %q expected [url]", fname, config.Args())
+	}
+	if config.flagSet.Name() != "remote add" {
+		t.Errorf("%s: received %q expected \"remote add\"",
+			fname, config.flagSet.Name())
+	}
+	subtree := Cmds(cmd[1], cmd[2], cmd[3])
+	if s := config.Node(cmd[1]).Subtree(); s != subtree {
+		t.Errorf("%s: received %s expected %s", fname, s, subtree)
+	}
+
+	// An unknown sub-command is left as an argument.
+	config, cmd, set = compose("remote", "ls", "-v")
+	if set != cmd[1] || config.Args()[0] != "ls" {
+		t.Errorf("%s: received %s %q expected %s [ls -v]",
+			fname, set, config.Args(), cmd[1])
+	}
+}
+
+func TestCommandTreeDuplicates(t *testing.T) {
+	const fname = "TestCommandTreeDuplicates"
+	config := &Config{}
+	_ = config.Command("Usage heading", "")
+	one := config.Node(config.Command("one", ""))
+	two := config.Node(config.Command("two", ""))
+	one.Command("add", "")
+	two.Command("add", "")
+	if config.errs != nil {
+		t.Errorf("%s: should not raise an error: %s", fname, config.errs)
+	}
+	one.Command("add", "")
+	if config.errs == nil {
+		t.Errorf("%s: expected a duplicate command error", fname)
+	}
+	n := config.Node(cmdBit(10)).Command("x", "")
+	if !errors.Is(config.errs, ErrUnknownCMD) || !n.Token().IsZero() {
+		t.Errorf("%s: %s", fname, config.errs)
+	}
+}
+
+func TestCommandTreeUsage(t *testing.T) {
+	const fname = "TestCommandTreeUsage"
+	config, cmd := testConfig("remote", "remote add")
+	opts := []Option{
+		{Type: String, Flag: "name", Default: "", Commands: cmd[2]},
+	}
+	if _, err := config.ComposeArgs([]string{"remote", "add"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	var buf bytes.Buffer
+	setUsageFn(&buf, config)
+	config.flagSet.Usage()
+	if !strings.Contains(buf.String(), "COMMAND\n        remote add\n") {
+		t.Errorf("%s: path not found in %q", fname, buf.String())
+	}
+	config.EnvPrefix("app")
+	if name := envName(config, config.set.options.find("name")); name != "APP_REMOTE_ADD_NAME" {
+		t.Errorf("%s: received %q expected \"APP_REMOTE_ADD_NAME\"", fname, name)
+	}
+	if key := storeKey(cmdPath(config, config.set, "."), "name"); key != "remote.add.name" {
+		t.Errorf("%s: received %q expected \"remote.add.name\"", fname, key)
+	}
+	m, o, err := storeOption(config, "remote.add.name")
+	if err != nil || m != config.set || o.Flag != "name" {
+		t.Errorf("%s: remote.add.name not resolved: %v", fname, err)
+	}
+}
+
+func TestCommandsUsage(t *testing.T) {
+	const fname = "TestCommandsUsage"
+	config := &Config{}
+	def := config.Command("Usage heading", "")
+	config.Node(config.Command("one", "")).Summary("the first")
+	config.Node(config.Command("two", "\n  the second\n  in full\n"))
+	config.Node(config.Command("three", ""))
+	remote := config.Node(config.Command("remote", "")).
+		Summary("manage remotes").Group("remotes")
+	remote.Command("add", "").Summary("add a remote")
+	opts := []Option{{Type: Int, Flag: "n", Default: 1, Commands: def}}
+	if _, err := config.ComposeArgs([]string{}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	var buf bytes.Buffer
+	setUsageFn(&buf, config)
+	config.flagSet.Usage()
+	want := "\n\nCOMMANDS\n" +
+		"        one     the first\n" +
+		"        two     the second\n" +
+		"        three\n" +
+		"\n        remotes\n" +
+		"        remote  manage remotes\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("%s: received %q expected %q", fname, buf.String(), want)
+	}
+	if strings.Contains(buf.String(), "add a remote") {
+		t.Errorf("%s: nested command listed in %q", fname, buf.String())
+	}
+
+	// The sub-commands of a nested command, none for a leaf.
+	config = &Config{}
+	config.Command("Usage heading", "")
+	remote = config.Node(config.Command("remote", ""))
+	remote.Command("add", "").Summary("add a remote")
+	opts = []Option{{Type: Int, Flag: "n", Default: 1,
+		Commands: remote.Token()}}
+	if _, err := config.ComposeArgs([]string{"remote"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	buf.Reset()
+	setUsageFn(&buf, config)
+	config.flagSet.Usage()
+	if !strings.Contains(buf.String(), "COMMANDS\n        add     add a remote\n") {
+		t.Errorf("%s: received %q", fname, buf.String())
+	}
+	config.Node(cmdBit(10)).Summary("x")
+	if !errors.Is(config.errs, ErrUnknownCMD) {
+		t.Errorf("%s: received %v expected %v", fname, config.errs, ErrUnknownCMD)
+	}
 }
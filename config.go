@@ -6,20 +6,12 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
-var (
-	// limit ensures that no more than 64 command sets are possible.
-	limit = CMD(math.MaxInt64>>1 + 1)
-	// test is used by the test package to stop the flagset from
-	// being parsed when the function Parse is called.
-	test bool
-)
-
 // Config contains an array of commands the user can call when starting
 // the command line application, the selected command then loads its
 // corresponding flagset and operating mode, parsing any following
@@ -47,14 +39,44 @@ type Config struct {
 	// All user commands created at start up, essentially bit masks
 	// their header strings and nomenclature.
 	commands []command
-	// The next available bit for use as a command bit mask
-	position CMD
 
 	// All possible flags.
 	all options
 	// The current running command set.
 	set *command
 
+	// app is the application name, used to name the directory in
+	// which the configuration file is stored.
+	app string
+	// store is the token of the built-in config command, empty if
+	// the command has not been registered.
+	store CMD
+
+	// files are the paths of the configuration files that are read
+	// in order when the Config is composed.
+	files []string
+
+	// stdout and stderr are the writers of the Config's output, nil
+	// for os.Stdout and os.Stderr.
+	stdout, stderr io.Writer
+
+	// handling is the behaviour of Compose when the arguments can
+	// not be parsed.
+	handling ErrorHandling
+
+	// groups are the flag groups, the relationships between flags
+	// that are checked once the flagset is parsed.
+	groups []group
+
+	// prog is the name of the program as used in the generated
+	// documentation and completion scripts, empty for the name by
+	// which the program was called.
+	prog string
+
+	// envPrefix when set, is used to derive the environment variable
+	// names of those options that do not define their own.
+	envPrefix string
+
 	// The flagset that is composed at startup according to the
 	// predefined command line commands and their options.
 	flagSet *flag.FlagSet
@@ -66,15 +88,33 @@ type Config struct {
 	errs error
 }
 
-// Compose initialises the programs options.
+// Compose initialises the programs options, parsing the command line
+// arguments found in os.Args.
 func (c *Config) Compose(opts ...Option) (set CMD, err error) {
-	const fname = "Config.Compose"
+	var args []string
+	if len(os.Args) > 1 {
+		args = os.Args[1:]
+	}
+	return c.ComposeArgs(args, opts...)
+}
+
+// ComposeArgs initialises the programs options, parsing the given
+// argument slice in place of os.Args; As with the flag package, args
+// should not contain the program name. ComposeArgs permits one program
+// to compose several Configs from differing argument vectors.
+func (c *Config) ComposeArgs(args []string, opts ...Option) (set CMD, err error) {
+	const fname = "Config.ComposeArgs"
 
 	if err = configPreconditions(c, opts...); err != nil {
 		err = fmt.Errorf("%s: %w", fname, err)
 		return
 	}
-	if set, err = ascertainCmdSet(c); err != nil {
+	// The hidden completion command is answered alone.
+	if len(args) > 0 && args[0] == completeCmd {
+		err = fmt.Errorf("%s: %w", fname, runComplete(c, args[1:], opts...))
+		return
+	}
+	if set, err = ascertainCmdSet(c, args); err != nil {
 		err = fmt.Errorf("%s: %w", fname, err)
 		return
 	}
@@ -82,7 +122,19 @@ func (c *Config) Compose(opts ...Option) (set CMD, err error) {
 		err = fmt.Errorf("%s: %w", fname, err)
 		return
 	}
-	if err = setupFlagSet(c); err != nil {
+	if err = setupFlagSet(c, args); err != nil {
+		err = fmt.Errorf("%s: %w", fname, err)
+		return
+	}
+	if err = runRequired(c); err != nil {
+		err = fmt.Errorf("%s: %w", fname, err)
+		return
+	}
+	if err = runGroups(c); err != nil {
+		err = fmt.Errorf("%s: %w", fname, err)
+		return
+	}
+	if err = runConstraints(c); err != nil {
 		err = fmt.Errorf("%s: %w", fname, err)
 		return
 	}
@@ -90,15 +142,18 @@ func (c *Config) Compose(opts ...Option) (set CMD, err error) {
 		err = fmt.Errorf("%s: %w", fname, err)
 		return
 	}
+	// When in mode 'config' record the requested settings.
+	if !c.store.IsZero() && set == c.store {
+		if err = runStore(c, stdout(c), c.Args()); err != nil {
+			err = fmt.Errorf("%s: %w", fname, err)
+			return
+		}
+	}
 
 	if v1() {
 		log.Printf("%s: completed\n", fname)
 	}
 
-	// TODO write a standard config file addition that records to a
-	// config file when in mode 'config' and that reads in any
-	// settings that have been previously recorded.
-	//c.loadConfig()
 	return
 }
 
@@ -127,7 +182,7 @@ func (c Config) IsSet(flag CMD) bool {
 	if c.set == nil {
 		panic("Config.set is nil, have you run Config.Compose?")
 	}
-	return c.set.flag&flag != 0
+	return c.set.flag.Has(flag)
 }
 
 // NArg returns the number of arguments remaining after the flags have
@@ -168,6 +223,22 @@ var (
 	ErrNotInCurrentSet = errors.New("flag not available in this set")
 	errCommands        = errors.New("commands not set")
 	ErrUnknownCMD      = errors.New("unknown CMD token")
+	// ErrChoice is returned when an option is given a value that is
+	// not one of its choices.
+	ErrChoice = errors.New("not a valid choice")
+	// ErrHelp is returned by Compose when help is requested by the
+	// -help or -h flags and the Config continues on error.
+	ErrHelp = flag.ErrHelp
+	// ErrComplete is returned by Compose when it has been called by a
+	// completion script, the candidates having been written, and the
+	// Config continues on error.
+	ErrComplete = errors.New("completion written")
+	// ErrRequired is wrapped by every RequiredError.
+	ErrRequired = errors.New("required flag missing")
+	// ErrGroup is wrapped by every GroupError.
+	ErrGroup = errors.New("flag group violated")
+	// ErrConstraint is wrapped by every ConstraintError.
+	ErrConstraint = errors.New("constraint violated")
 )
 
 /* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -214,6 +285,38 @@ const (
 	DurationVar
 	// Var are the interface{} type.
 	Var
+	// StringSlice are the native []string type.
+	StringSlice
+	// StringSliceVar are the native &[]string type.
+	StringSliceVar
+	// IntSlice are the native []int type.
+	IntSlice
+	// IntSliceVar are the native &[]int type.
+	IntSliceVar
+	// Float64Slice are the native []float64 type.
+	Float64Slice
+	// Float64SliceVar are the native &[]float64 type.
+	Float64SliceVar
+	// DurationSlice are the []time.Duration type.
+	DurationSlice
+	// DurationSliceVar are the &[]time.Duration type.
+	DurationSliceVar
+	// StringMap are the native map[string]string type.
+	StringMap
+	// StringMapVar are the native &map[string]string type.
+	StringMapVar
+	// IntMap are the native map[string]int type.
+	IntMap
+	// IntMapVar are the native &map[string]int type.
+	IntMapVar
+	// Float64Map are the native map[string]float64 type.
+	Float64Map
+	// Float64MapVar are the native &map[string]float64 type.
+	Float64MapVar
+	// DurationMap are the map[string]time.Duration type.
+	DurationMap
+	// DurationMapVar are the &map[string]time.Duration type.
+	DurationMapVar
 	// Default are an unknown type.
 	Default
 )
@@ -260,11 +363,59 @@ func (t Type) String() string {
 		return "*time.Duration"
 	case Var:
 		return "flag.Value"
+	case StringSlice:
+		return "[]string"
+	case StringSliceVar:
+		return "*[]string"
+	case IntSlice:
+		return "[]int"
+	case IntSliceVar:
+		return "*[]int"
+	case Float64Slice:
+		return "[]float64"
+	case Float64SliceVar:
+		return "*[]float64"
+	case DurationSlice:
+		return "[]time.Duration"
+	case DurationSliceVar:
+		return "*[]time.Duration"
+	case StringMap:
+		return "map[string]string"
+	case StringMapVar:
+		return "*map[string]string"
+	case IntMap:
+		return "map[string]int"
+	case IntMapVar:
+		return "*map[string]int"
+	case Float64Map:
+		return "map[string]float64"
+	case Float64MapVar:
+		return "*map[string]float64"
+	case DurationMap:
+		return "map[string]time.Duration"
+	case DurationMapVar:
+		return "*map[string]time.Duration"
 	default:
 		return "error: unknown type"
 	}
 }
 
+// lookup returns the option for the given flag from the current running
+// command set.
+func lookup(c *Config, flag string) (*Option, error) {
+	if c.set == nil {
+		return nil, errCommands
+	}
+	o := c.set.options.find(flag)
+	if o == nil && c.Is(flag) {
+		return nil, ErrNotInCurrentSet
+	}
+	if o == nil {
+		return nil, errNoFlag
+	}
+	return o, nil
+}
+
 // Value returns the content of an option along with its type, else an
 // error, if one has been raised during the options creation.
 func (c Config) Value(key string) (interface{}, Type, error) {
@@ -272,15 +423,9 @@ func (c Config) Value(key string) (interface{}, Type, error) {
 	fail := func(err error) (interface{}, Type, error) {
 		return nil, Nil, fmt.Errorf("%s: %w", fname, err)
 	}
-	if c.set == nil {
-		return fail(errCommands)
-	}
-	o := c.set.options.find(key)
-	if o == nil && c.Is(key) {
-		return fail(ErrNotInCurrentSet)
-	}
-	if o == nil {
-		return fail(errNoFlag)
+	o, err := lookup(&c, key)
+	if err != nil {
+		return fail(err)
 	}
 	if o.err != nil {
 		return fail(o.err)
@@ -291,279 +436,277 @@ func (c Config) Value(key string) (interface{}, Type, error) {
 	return o.data, o.Type, nil
 }
 
-// ValueInt returns the value of an int option, else an error if one has
-// been raised during the options creation.
-func (c Config) ValueInt(key string) (int, error) {
-	const fname = "ValueInt"
-	var out int
-	fail := func(err error) (int, error) {
+// Get returns the value of an option as the type T, else an error if one
+// has been raised during the options creation or if T is not the type
+// of the option. The value of an option of the Var type is its
+// flag.Value asserted to T, that of the native Var types, IntVar et al,
+// is read through the pointer given in the options Var field.
+//
+//	n, err := conf.Get[int](c, "n")
+func Get[T any](c *Config, key string) (T, error) {
+	const fname = "Get"
+	out, err := get[T](c, key)
+	if err != nil {
 		return out, fmt.Errorf("%s: %w", fname, err)
 	}
-	if c.set == nil {
-		return fail(errCommands)
+	return out, nil
+}
+
+// MustGet returns the value of an option as the type T, it panics if
+// Get would have returned an error.
+func MustGet[T any](c *Config, key string) T {
+	out, err := Get[T](c, key)
+	if err != nil {
+		panic(err)
 	}
-	o := c.set.options.find(key)
-	if o == nil && c.Is(key) {
-		return fail(ErrNotInCurrentSet)
+	return out
+}
+
+// getValue returns the value held in the options data as the type T,
+// any error being prefixed with fname, the name of the calling method;
+// As with Value, errNoData is returned for the options of the Var types
+// that hold their value in their Var alone.
+func getValue[T any](c *Config, fname, key string) (T, error) {
+	var out T
+	fail := func(err error) (T, error) {
+		return out, fmt.Errorf("%s: %w", fname, err)
 	}
-	if o == nil {
-		return fail(errNoFlag)
+	o, err := lookup(c, key)
+	if err != nil {
+		return fail(err)
 	}
 	if o.err != nil {
 		return fail(o.err)
 	}
-	switch t := o.data.(type) {
-	case nil:
+	if o.data == nil {
 		return fail(errNoData)
-	case *int:
-		out = *t
-	default:
-		return fail(want(out, t))
 	}
-	return out, nil
+	p, ok := o.data.(*T)
+	if !ok {
+		return fail(want(out, o.data))
+	}
+	return *p, nil
 }
 
-// ValueInt64 returns the value of an int64 option, else an error if one
-// has been raised during the options creation.
-func (c Config) ValueInt64(key string) (int64, error) {
-	const fname = "ValueInt64"
-	var out int64
-	fail := func(err error) (int64, error) {
-		return out, fmt.Errorf("%s: %w", fname, err)
-	}
-	if c.set == nil {
-		return fail(errCommands)
-	}
-	o := c.set.options.find(key)
-	if o == nil && c.Is(key) {
-		return fail(ErrNotInCurrentSet)
-	}
-	if o == nil {
-		return fail(errNoFlag)
+// get returns the value of the option as the type T; That of the native
+// Var types is read through the pointer in the options Var field, such
+// that it reflects any later change that the program makes to the
+// variable.
+func get[T any](c *Config, key string) (T, error) {
+	var out T
+	o, err := lookup(c, key)
+	if err != nil {
+		return out, err
 	}
 	if o.err != nil {
-		return fail(o.err)
+		return out, o.err
 	}
-	switch t := o.data.(type) {
-	case nil:
-		return fail(errNoData)
-	case *int64:
-		out = *t
-	default:
-		return fail(want(out, t))
+	switch {
+	case o.Type == Var:
+		if o.Value == nil {
+			return out, errNoData
+		}
+		v, ok := o.Value.(T)
+		if !ok {
+			return out, want(out, o.Value)
+		}
+		return v, nil
+	case o.data != nil:
+		p, ok := o.data.(*T)
+		if !ok {
+			return out, want(out, o.data)
+		}
+		return *p, nil
+	case o.Var != nil:
+		p, ok := o.Var.(*T)
+		if !ok {
+			return out, want(out, o.Var)
+		}
+		return *p, nil
 	}
-	return out, nil
+	return out, errNoData
+}
+
+// ValueInt returns the value of an int option, else an error if one has
+// been raised during the options creation.
+func (c Config) ValueInt(key string) (int, error) {
+	return getValue[int](&c, "ValueInt", key)
+}
+
+// ValueInt64 returns the value of an int64 option, else an error if one
+// has been raised during the options creation.
+func (c Config) ValueInt64(key string) (int64, error) {
+	return getValue[int64](&c, "ValueInt64", key)
 }
 
 // ValueUint returns the value of an uint option, else an error if one has
 // been raised during the options creation.
 func (c Config) ValueUint(key string) (uint, error) {
-	const fname = "ValueUint"
-	var out uint
-	fail := func(err error) (uint, error) {
-		return out, fmt.Errorf("%s: %w", fname, err)
-	}
-	if c.set == nil {
-		return fail(errCommands)
-	}
-	o := c.set.options.find(key)
-	if o == nil && c.Is(key) {
-		return fail(ErrNotInCurrentSet)
-	}
-	if o == nil {
-		return fail(errNoFlag)
-	}
-	if o.err != nil {
-		return fail(o.err)
-	}
-	switch t := o.data.(type) {
-	case nil:
-		return fail(errNoData)
-	case *uint:
-		out = *t
-	default:
-		return fail(want(out, t))
-	}
-	return out, nil
+	return getValue[uint](&c, "ValueUint", key)
 }
 
 // ValueUint64 returns the value of an uint64 option, else an error if one
 // has been raised during the options creation.
 func (c Config) ValueUint64(key string) (uint64, error) {
-	const fname = "ValueUint64"
-	var out uint64
-	fail := func(err error) (uint64, error) {
-		return out, fmt.Errorf("%s: %w", fname, err)
-	}
-	if c.set == nil {
-		return fail(errCommands)
-	}
-	o := c.set.options.find(key)
-	if o == nil && c.Is(key) {
-		return fail(ErrNotInCurrentSet)
-	}
-	if o == nil {
-		return fail(errNoFlag)
-	}
-	if o.err != nil {
-		return fail(o.err)
-	}
-	switch t := o.data.(type) {
-	case nil:
-		return fail(errNoData)
-	case *uint64:
-		out = *t
-	default:
-		return fail(want(out, t))
-	}
-	return out, nil
+	return getValue[uint64](&c, "ValueUint64", key)
 }
 
 // ValueFloat64 returns the value of an float64 option, else an error if
 // one has been raised during the options creation.
 func (c Config) ValueFloat64(key string) (float64, error) {
-	const fname = "ValueFloat64"
-	var out float64
-	fail := func(err error) (float64, error) {
-		return out, fmt.Errorf("%s: %w", fname, err)
-	}
-	if c.set == nil {
-		return fail(errCommands)
-	}
-	o := c.set.options.find(key)
-	if o == nil && c.Is(key) {
-		return fail(ErrNotInCurrentSet)
-	}
-	if o == nil {
-		return fail(errNoFlag)
-	}
-	if o.err != nil {
-		return fail(o.err)
-	}
-	switch t := o.data.(type) {
-	case nil:
-		return fail(errNoData)
-	case *float64:
-		out = *t
-	default:
-		return fail(want(out, t))
-	}
-	return out, nil
+	return getValue[float64](&c, "ValueFloat64", key)
 }
 
 // ValueString returns the value of an string option, else an error if one
 // has been raised during the options creation.
 func (c Config) ValueString(key string) (string, error) {
-	const fname = "ValueString"
-	var out string
-	fail := func(err error) (string, error) {
-		return out, fmt.Errorf("%s: %w", fname, err)
-	}
-	if c.set == nil {
-		return fail(errCommands)
-	}
-	o := c.set.options.find(key)
-	if o == nil && c.Is(key) {
-		return fail(ErrNotInCurrentSet)
-	}
-	if o == nil {
-		return fail(errNoFlag)
-	}
-	if o.err != nil {
-		return fail(o.err)
-	}
-	switch t := o.data.(type) {
-	case nil:
-		return fail(errNoData)
-	case *string:
-		out = *t
-	default:
-		return fail(want(out, t))
-	}
-	return out, nil
+	return getValue[string](&c, "ValueString", key)
 }
 
-// ValueBool returns the value of an string option, else an error if one
+// ValueBool returns the value of an bool option, else an error if one
 // has been raised during the options creation.
 func (c Config) ValueBool(key string) (bool, error) {
-	const fname = "ValueBool"
-	var out bool
-	fail := func(err error) (bool, error) {
-		return out, fmt.Errorf("%s: %w", fname, err)
-	}
-	if c.set == nil {
-		return fail(errCommands)
-	}
-	o := c.set.options.find(key)
-	if o == nil && c.Is(key) {
-		return fail(ErrNotInCurrentSet)
-	}
-	if o == nil {
-		return fail(errNoFlag)
-	}
-	if o.err != nil {
-		return fail(o.err)
-	}
-	switch t := o.data.(type) {
-	case nil:
-		return fail(errNoData)
-	case *bool:
-		out = *t
-	default:
-		return fail(want(out, t))
-	}
-	return out, nil
+	return getValue[bool](&c, "ValueBool", key)
 }
 
 // ValueDuration returns the value of an time.Duration option, else an
 // error if one has been raised during the options creation.
 func (c Config) ValueDuration(key string) (time.Duration, error) {
-	const fname = "ValueDuration"
-	var out time.Duration
-	fail := func(err error) (time.Duration, error) {
-		return out, fmt.Errorf("%s: %w:", fname, err)
-	}
-	if c.set == nil {
-		return fail(errCommands)
-	}
-	o := c.set.options.find(key)
-	if o == nil && c.Is(key) {
-		return fail(ErrNotInCurrentSet)
-	}
-	if o == nil {
-		return fail(errNoFlag)
-	}
-	if o.err != nil {
-		return fail(o.err)
-	}
-	switch t := o.data.(type) {
-	case nil:
-		return fail(errNoData)
-	case *time.Duration:
-		out = *t
-	default:
-		return fail(want(out, t))
-	}
-	return out, nil
+	return getValue[time.Duration](&c, "ValueDuration", key)
+}
+
+// ValueStringSlice returns the value of an []string option, else an
+// error if one has been raised during the options creation.
+func (c Config) ValueStringSlice(key string) ([]string, error) {
+	return getValue[[]string](&c, "ValueStringSlice", key)
+}
+
+// ValueIntSlice returns the value of an []int option, else an
+// error if one has been raised during the options creation.
+func (c Config) ValueIntSlice(key string) ([]int, error) {
+	return getValue[[]int](&c, "ValueIntSlice", key)
+}
+
+// ValueFloat64Slice returns the value of an []float64 option, else an
+// error if one has been raised during the options creation.
+func (c Config) ValueFloat64Slice(key string) ([]float64, error) {
+	return getValue[[]float64](&c, "ValueFloat64Slice", key)
+}
+
+// ValueDurationSlice returns the value of an []time.Duration option, else an
+// error if one has been raised during the options creation.
+func (c Config) ValueDurationSlice(key string) ([]time.Duration, error) {
+	return getValue[[]time.Duration](&c, "ValueDurationSlice", key)
+}
+
+// ValueStringMap returns the value of a map[string]string option, else an
+// error if one has been raised during the options creation.
+func (c Config) ValueStringMap(key string) (map[string]string, error) {
+	return getValue[map[string]string](&c, "ValueStringMap", key)
+}
+
+// ValueIntMap returns the value of a map[string]int option, else an
+// error if one has been raised during the options creation.
+func (c Config) ValueIntMap(key string) (map[string]int, error) {
+	return getValue[map[string]int](&c, "ValueIntMap", key)
+}
+
+// ValueFloat64Map returns the value of a map[string]float64 option, else an
+// error if one has been raised during the options creation.
+func (c Config) ValueFloat64Map(key string) (map[string]float64, error) {
+	return getValue[map[string]float64](&c, "ValueFloat64Map", key)
+}
+
+// ValueDurationMap returns the value of a map[string]time.Duration
+// option, else an error if one has been raised during the options
+// creation.
+func (c Config) ValueDurationMap(key string) (map[string]time.Duration, error) {
+	return getValue[map[string]time.Duration](&c, "ValueDurationMap", key)
 }
 
 /* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
  *  Usage display
  * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
 
-// setUsageFn is set as flag.FlagSet.Usage, generating the usage output.
+// SetOutput sets the writers to which the Config writes its output,
+// requested help and the output of the built-in config command being
+// written to stdout, errors and the usage that accompanies them to
+// stderr. A nil writer leaves os.Stdout or os.Stderr in use.
+//
+//	var out, errs bytes.Buffer
+//	c.SetOutput(&out, &errs)
+func (c *Config) SetOutput(stdout, stderr io.Writer) {
+	c.stdout, c.stderr = stdout, stderr
+	if c.flagSet != nil {
+		setUsageFn(nil, c)
+	}
+}
+
+// stdout returns the Config's standard output.
+func stdout(c *Config) io.Writer {
+	if c.stdout == nil {
+		return os.Stdout
+	}
+	return c.stdout
+}
+
+// stderr returns the Config's error output.
+func stderr(c *Config) io.Writer {
+	if c.stderr == nil {
+		return os.Stderr
+	}
+	return c.stderr
+}
+
+// ProgName sets the name of the program as it is given in the
+// generated man pages, reference, diagrams and completion scripts, by
+// default the name by which the program was called; Set it when these
+// are written by a program other than the one that they describe.
+//
+//	c.ProgName("app")
+func (c *Config) ProgName(name string) {
+	c.prog = name
+}
+
+// progName returns the name of the program, as used by the generated
+// completion scripts and documentation.
+func progName(c *Config) string {
+	if c.prog != "" {
+		return c.prog
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// setUsageFn is set as flag.FlagSet.Usage, generating the usage output,
+// written to w, or to the Config's error output when w is nil; The
+// flagsets own error messages are written to the same writer.
 func setUsageFn(w io.Writer, c *Config) {
-	c.flagSet.SetOutput(w)
 	if w == nil {
-		w = os.Stderr
+		w = stderr(c)
 	}
+	c.flagSet.SetOutput(w)
 	c.flagSet.Usage = func() {
-		io.WriteString(w, c.header)
-		io.WriteString(w, c.set.usage)
-		c.flagSet.VisitAll(flagUsage)
+		writeUsage(c, w)
 	}
 }
 
+// writeUsage writes the usage output of the running command to w.
+func writeUsage(c *Config, w io.Writer) {
+	io.WriteString(w, c.header)
+	// Nested commands are shown by their full path.
+	if c.set.depth > 1 {
+		fmt.Fprintf(w, "\n\nCOMMAND\n        %s\n",
+			c.flagSet.Name())
+	}
+	// Sub-commands are listed automatically.
+	io.WriteString(w, commandsUsage(c))
+	io.WriteString(w, c.set.usage)
+	c.flagSet.VisitAll(func(f *flag.Flag) {
+		flagUsage(w, f)
+	})
+	io.WriteString(w, groupsUsage(c))
+}
+
 // space sets a space after the flag name in the help output, aligning the
 // flags description correctly for output.
 func space(b []byte, l int) string {
@@ -577,7 +720,7 @@ func space(b []byte, l int) string {
 }
 
 // flagUsage writes the usage message for each individual flag.
-func flagUsage(f *flag.Flag) {
+func flagUsage(w io.Writer, f *flag.Flag) {
 	l := len(f.Name) + 1 // for the '-' char.
 	var buf [8]byte
 	sp := space(buf[:], l)
@@ -587,5 +730,5 @@ func flagUsage(f *flag.Flag) {
 		s += "\n        \t"
 	}
 	s += strings.ReplaceAll(usage, "\n", "\n            \t")
-	fmt.Fprint(os.Stdout, s, "\n\n")
+	fmt.Fprint(w, s, "\n\n")
 }
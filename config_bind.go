@@ -0,0 +1,434 @@
+package conf
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bind generates an option for every exported field of the struct that
+// v points to, the options writing their values directly into the
+// fields when the Config is composed, such that the struct is populated
+// once Compose has returned. Commands must have been registered before
+// Bind is called. Fields are described by their `conf` tag, a flag name
+// followed by a comma separated list of key value pairs, values that
+// contain commas may be enclosed in single quotes. The usage key must
+// come last, its value being the remainder of the tag; Any key other
+// than those below is an error.
+//
+//	type Settings struct {
+//		Port int    `conf:"p,default=8080,cmd=serve|run,usage=the port to listen on"`
+//		Host string `conf:"host,env=APP_HOST"`
+//		Skip int    `conf:"-"`
+//	}
+//
+//	name     the flag name, the lower cased field name when left empty.
+//	default  the default value, the fields value when not given.
+//	cmd      the paths of the commands that the option belongs to,
+//	         separated by the | character, nested command names being
+//	         joined by dots, remote.add; The default set is named by a
+//	         single dot. When not given the option belongs to the command
+//	         of the enclosing struct, else to the default set.
+//	env      the name of an environment variable for the option.
+//	sep      the separator of a slice's or map's values, a comma by
+//	         default.
+//	dup      the key policy of a map, one of last first or error.
+//	choices  the values that the option accepts, separated by the |
+//	         character.
+//	min max  the limits of a numeric value.
+//	minlen maxlen
+//	         the limits of the length of a string, slice or map.
+//	pattern  a regular expression that a string must match.
+//	nonempty requires that a string, slice or map is not empty.
+//	required the option must be given in all of its commands.
+//	usage    the usage text.
+//
+// Fields of the int, int64, uint, uint64, float64, string, bool and
+// time.Duration types are supported, as are slices of string, int,
+// float64 and time.Duration, maps of string keys to those same types,
+// and types that implement flag.Value through a pointer receiver. A
+// nested struct prefixes the flag names of its fields with its own name
+// and a dash, unless it is tagged as a command, in which case its
+// fields belong to the named sub-command of the enclosing struct's
+// command. Embedded structs are flattened.
+//
+//	type Settings struct {
+//		DB struct {
+//			Host string `conf:"host"`   // -db-host
+//		} `conf:"db"`
+//		Remote struct {
+//			Add struct {
+//				Name string `conf:"name"` // app remote add -name
+//			} `conf:"add,command"`
+//		} `conf:"remote,command"`
+//	}
+func (c *Config) Bind(v interface{}) ([]Option, error) {
+	const fname = "Config.Bind"
+	if len(c.commands) == 0 {
+		return nil, fmt.Errorf("%s: %s: %w", fname, errCommands, errConfig)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s: %T: not a pointer to a struct: %w",
+			fname, v, errConfig)
+	}
+	opts, err := bindStruct(c, rv.Elem(), "", &c.commands[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	return opts, nil
+}
+
+// flagValueType is the reflected flag.Value interface type.
+var flagValueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
+
+// durationType is the reflected time.Duration type.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// bindStruct generates the options for the fields of the struct value,
+// prefix is prepended to every flag name and m is the command that the
+// options belong to by default.
+func bindStruct(c *Config, rv reflect.Value, prefix string, m *command) ([]Option, error) {
+	const fname = "bindStruct"
+	var opts []Option
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag, ok := field.Tag.Lookup("conf")
+		if tag == "-" {
+			continue
+		}
+		name, keys, err := parseTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", fname, field.Name, err)
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fv := rv.Field(i)
+
+		// Nested structs.
+		if field.Type.Kind() == reflect.Struct &&
+			!reflect.PtrTo(field.Type).Implements(flagValueType) {
+			sub, subPrefix := m, prefix+name+"-"
+			if field.Anonymous && !ok {
+				subPrefix = prefix
+			}
+			if _, isCmd := keys["command"]; isCmd {
+				path := name
+				if p := cmdPath(c, m, "."); p != "" {
+					path = p + "." + name
+				}
+				if sub, err = commandByPath(c, path); err != nil {
+					return nil, fmt.Errorf("%s: %s: %w",
+						fname, field.Name, err)
+				}
+				subPrefix = ""
+			}
+			o, err := bindStruct(c, fv, subPrefix, sub)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, o...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		o, err := bindField(fv, field)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", fname, field.Name, err)
+		}
+		o.Flag = prefix + name
+		o.Usage = keys["usage"]
+		o.Env = keys["env"]
+		o.Separator = keys["sep"]
+		if choices, ok := keys["choices"]; ok {
+			o.Choices = strings.Split(choices, "|")
+		}
+		if dup, ok := keys["dup"]; ok {
+			if o.Duplicate, err = keyPolicy(dup); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w",
+					fname, field.Name, err)
+			}
+		}
+		o.Commands = m.flag
+		if def, ok := keys["default"]; ok {
+			if err := bindDefault(&o, def); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w",
+					fname, field.Name, err)
+			}
+		}
+		if err := bindConstraints(&o, keys); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", fname, field.Name, err)
+		}
+		if cmds, ok := keys["cmd"]; ok {
+			o.Commands = CMD{}
+			for _, path := range strings.Split(cmds, "|") {
+				m, err := commandByPath(c, path)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s: %w",
+						fname, field.Name, err)
+				}
+				o.Commands = o.Commands.Or(m.flag)
+			}
+		}
+		if _, ok := keys["required"]; ok {
+			o.Required = o.Commands
+		}
+		opts = append(opts, o)
+	}
+	return opts, nil
+}
+
+// bindField returns an option for the field, its type, Var and Default
+// being set from the field.
+func bindField(fv reflect.Value, field reflect.StructField) (Option, error) {
+	const fname = "bindField"
+	var o Option
+	ptr := fv.Addr().Interface()
+	if v, ok := ptr.(flag.Value); ok {
+		o.Type, o.Value = Var, v
+		return o, nil
+	}
+	o.Var = ptr
+	o.Default = fv.Interface()
+	switch field.Type {
+	case durationType:
+		o.Type = DurationVar
+		return o, nil
+	case reflect.SliceOf(durationType):
+		o.Type = DurationSliceVar
+		return o, nil
+	case reflect.MapOf(reflect.TypeOf(""), durationType):
+		o.Type = DurationMapVar
+		return o, nil
+	}
+	// Named types can not be used as their Var and Default would
+	// not match the options type.
+	if field.Type.PkgPath() != "" {
+		return o, fmt.Errorf("%s: %s: %w", fname, field.Type, errType)
+	}
+	switch field.Type.Kind() {
+	case reflect.Int:
+		o.Type = IntVar
+	case reflect.Int64:
+		o.Type = Int64Var
+	case reflect.Uint:
+		o.Type = UintVar
+	case reflect.Uint64:
+		o.Type = Uint64Var
+	case reflect.Float64:
+		o.Type = Float64Var
+	case reflect.String:
+		o.Type = StringVar
+	case reflect.Bool:
+		o.Type = BoolVar
+	case reflect.Slice:
+		switch field.Type.Elem().Kind() {
+		case reflect.String:
+			o.Type = StringSliceVar
+		case reflect.Int:
+			o.Type = IntSliceVar
+		case reflect.Float64:
+			o.Type = Float64SliceVar
+		}
+	case reflect.Map:
+		if field.Type.Key() != reflect.TypeOf("") {
+			break
+		}
+		switch field.Type.Elem().Kind() {
+		case reflect.String:
+			o.Type = StringMapVar
+		case reflect.Int:
+			o.Type = IntMapVar
+		case reflect.Float64:
+			o.Type = Float64MapVar
+		}
+	}
+	// Slices and maps of named types are also excluded.
+	if o.Type == Nil || (field.Type.Kind() == reflect.Slice ||
+		field.Type.Kind() == reflect.Map) && field.Type.Elem().PkgPath() != "" {
+		return o, fmt.Errorf("%s: %s: %w", fname, field.Type, errType)
+	}
+	return o, nil
+}
+
+// bindDefault parses the default value from its string form into the
+// options type.
+func bindDefault(o *Option, s string) error {
+	const fname = "bindDefault"
+	var err error
+	switch o.Type {
+	case IntVar:
+		o.Default, err = strconv.Atoi(s)
+	case Int64Var:
+		o.Default, err = strconv.ParseInt(s, 0, 64)
+	case UintVar:
+		var u uint64
+		u, err = strconv.ParseUint(s, 0, strconv.IntSize)
+		o.Default = uint(u)
+	case Uint64Var:
+		o.Default, err = strconv.ParseUint(s, 0, 64)
+	case Float64Var:
+		o.Default, err = strconv.ParseFloat(s, 64)
+	case StringVar:
+		o.Default = s
+	case BoolVar:
+		o.Default, err = strconv.ParseBool(s)
+	case DurationVar:
+		o.Default, err = time.ParseDuration(s)
+	case StringSliceVar:
+		o.Default, err = splitSlice(s, separator(o), parseString)
+	case IntSliceVar:
+		o.Default, err = splitSlice(s, separator(o), strconv.Atoi)
+	case Float64SliceVar:
+		o.Default, err = splitSlice(s, separator(o), parseFloat64)
+	case DurationSliceVar:
+		o.Default, err = splitSlice(s, separator(o), time.ParseDuration)
+	case StringMapVar:
+		o.Default, err = splitMap(s, separator(o), parseString)
+	case IntMapVar:
+		o.Default, err = splitMap(s, separator(o), strconv.Atoi)
+	case Float64MapVar:
+		o.Default, err = splitMap(s, separator(o), parseFloat64)
+	case DurationMapVar:
+		o.Default, err = splitMap(s, separator(o), time.ParseDuration)
+	case Var:
+		err = o.Value.Set(s)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s: %q: %s: %w", fname, o.Type, s, err, errType)
+	}
+	return nil
+}
+
+// bindConstraints sets the options constraints from the tags keys, the
+// limits of min and max being parsed as is the default.
+func bindConstraints(o *Option, keys map[string]string) error {
+	const fname = "bindConstraints"
+	for _, limit := range []struct {
+		key string
+		v   *interface{}
+	}{{RuleMin, &o.Min}, {RuleMax, &o.Max}} {
+		s, ok := keys[limit.key]
+		if !ok {
+			continue
+		}
+		if !isNumeric(o.Type) {
+			return fmt.Errorf("%s: %s: %s: %w",
+				fname, o.Type, limit.key, errType)
+		}
+		tmp := *o
+		if err := bindDefault(&tmp, s); err != nil {
+			return fmt.Errorf("%s: %s: %w", fname, limit.key, err)
+		}
+		*limit.v = tmp.Default
+	}
+	for _, limit := range []struct {
+		key string
+		n   *int
+	}{{RuleMinLen, &o.MinLen}, {RuleMaxLen, &o.MaxLen}} {
+		s, ok := keys[limit.key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %q: %w",
+				fname, limit.key, s, errNotValid)
+		}
+		*limit.n = n
+	}
+	o.Pattern = keys[RulePattern]
+	_, o.NonEmpty = keys[RuleNonEmpty]
+	return nil
+}
+
+// keyPolicy returns the key policy with the given name.
+func keyPolicy(name string) (KeyPolicy, error) {
+	const fname = "keyPolicy"
+	for _, k := range []KeyPolicy{KeyLast, KeyFirst, KeyError} {
+		if k.String() == name {
+			return k, nil
+		}
+	}
+	return KeyLast, fmt.Errorf("%s: %q: %w", fname, name, errNotValid)
+}
+
+// commandByPath returns the command with the given path, its names
+// joined by dots, the default set being named by a single dot.
+func commandByPath(c *Config, path string) (*command, error) {
+	const fname = "commandByPath"
+	if path == "." {
+		return &c.commands[0], nil
+	}
+	for i := range c.commands[1:] {
+		if cmdPath(c, &c.commands[i+1], ".") == path {
+			return &c.commands[i+1], nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %q: %w", fname, path, ErrUnknownCMD)
+}
+
+// tagKeys are the keys that may be given in a `conf` tag.
+var tagKeys = map[string]bool{
+	"name": true, "default": true, "cmd": true, "env": true,
+	"sep": true, "dup": true, "choices": true, "min": true, "max": true,
+	"minlen": true, "maxlen": true, "pattern": true, "nonempty": true,
+	"required": true, "usage": true, "command": true,
+}
+
+// parseTag splits a struct tag into its flag name and its keys, values
+// may be enclosed in single quotes so as to contain commas, the usage
+// key takes the remainder of the tag; A quote opens only at the start of
+// a value, such that an apostrophe may be used elsewhere.
+func parseTag(tag string) (string, map[string]string, error) {
+	const fname = "parseTag"
+	keys := make(map[string]string)
+	var parts []string
+	var quoted bool
+	start := 0
+	for i := 0; i <= len(tag); i++ {
+		if i == start && strings.HasPrefix(tag[start:], "usage=") {
+			keys["usage"] = tag[start+len("usage="):]
+			break
+		}
+		if i < len(tag) && tag[i] == '\'' &&
+			(quoted || i > 0 && tag[i-1] == '=') {
+			quoted = !quoted
+			continue
+		}
+		if i < len(tag) && (tag[i] != ',' || quoted) {
+			continue
+		}
+		parts = append(parts, tag[start:i])
+		start = i + 1
+	}
+	if quoted {
+		return "", nil, fmt.Errorf("%s: %q: unbalanced quote: %w",
+			fname, tag, errNotValid)
+	}
+	if len(parts) == 0 {
+		return "", keys, nil
+	}
+	for _, part := range parts[1:] {
+		k, v, _ := strings.Cut(part, "=")
+		if !tagKeys[k] {
+			return "", nil, fmt.Errorf("%s: %q: unknown key: %w",
+				fname, k, errNotValid)
+		}
+		if len(v) > 1 && v[0] == '\'' && v[len(v)-1] == '\'' {
+			v = v[1 : len(v)-1]
+		}
+		keys[k] = v
+	}
+	return parts[0], keys, nil
+}
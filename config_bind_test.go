@@ -0,0 +1,151 @@
+package conf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindSettings struct {
+	Port    int           `conf:"p,default=8080,cmd=serve|run,usage=the server's port, to listen on"`
+	Host    string        `conf:"host,env=BIND_HOST"`
+	Verbose bool          `conf:",cmd=.|serve|remote.add"`
+	Wait    time.Duration `conf:"wait,default='1s',cmd=run"`
+	Value   testValue     `conf:"value,cmd=serve"`
+	Skip    int           `conf:"-"`
+	private int
+	DB      struct {
+		Host string `conf:"host,default=localhost,cmd=serve"`
+	} `conf:"db"`
+	Remote struct {
+		Add struct {
+			Name string `conf:"name"`
+		} `conf:"add,command"`
+	} `conf:"remote,command"`
+}
+
+func TestBind(t *testing.T) {
+	const fname = "TestBind"
+	t.Setenv("BIND_HOST", "example.com")
+	config, _ := testConfig("serve", "run", "remote", "remote add")
+	settings := bindSettings{Host: "host"}
+	opts, err := config.Bind(&settings)
+	if err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	flags := []string{"p", "host", "verbose", "wait", "value", "db-host", "name"}
+	if len(opts) != len(flags) {
+		t.Fatalf("%s: received %d options expected %d", fname, len(opts), len(flags))
+	}
+	for i, o := range opts {
+		if o.Flag != flags[i] {
+			t.Errorf("%s: received %q expected %q", fname, o.Flag, flags[i])
+		}
+	}
+	if opts[0].Usage != "the server's port, to listen on" || opts[0].Default != 8080 {
+		t.Errorf("%s: received %q %v", fname, opts[0].Usage, opts[0].Default)
+	}
+	if opts[1].Default != "host" || opts[1].Env != "BIND_HOST" {
+		t.Errorf("%s: received %v %q", fname, opts[1].Default, opts[1].Env)
+	}
+	args := []string{"serve", "-p", "9090", "-verbose", "-value", "v", "-db-host", "db"}
+	if _, err := config.ComposeArgs(args, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if settings.Port != 9090 || !settings.Verbose || settings.Host != "host" ||
+		settings.DB.Host != "db" || settings.Wait != 0 || settings.Value.str != "" {
+		t.Errorf("%s: received %+v", fname, settings)
+	}
+
+	config, _ = testConfig("serve", "run", "remote", "remote add")
+	settings = bindSettings{}
+	if opts, err = config.Bind(&settings); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	args = []string{"remote", "add", "-name", "origin", "-verbose"}
+	if _, err := config.ComposeArgs(args, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if settings.Remote.Add.Name != "origin" || !settings.Verbose || settings.Port != 0 {
+		t.Errorf("%s: received %+v", fname, settings)
+	}
+
+	config, _ = testConfig("serve", "run", "remote", "remote add")
+	settings = bindSettings{}
+	if opts, err = config.Bind(&settings); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if _, err := config.ComposeArgs([]string{"run"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if settings.Port != 8080 || settings.Wait != time.Second || settings.Host != "" {
+		t.Errorf("%s: received %+v", fname, settings)
+	}
+	config, _ = testConfig("serve", "run", "remote", "remote add")
+	if opts, err = config.Bind(&settings); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if _, err := config.ComposeArgs(nil, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if settings.Host != "example.com" {
+		t.Errorf("%s: received %q expected \"example.com\"", fname, settings.Host)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	const fname = "TestBindErrors"
+	tests := []struct {
+		v   interface{}
+		err error
+	}{
+		{v: bindSettings{}, err: errConfig},
+		{v: &struct {
+			N int `conf:"n,cmd=nothere"`
+		}{}, err: ErrUnknownCMD},
+		{v: &struct {
+			N int `conf:"n,default=x"`
+		}{}, err: errType},
+		{v: &struct {
+			N []bool `conf:"n"`
+		}{}, err: errType},
+		{v: &struct {
+			N []time.Month `conf:"n"`
+		}{}, err: errType},
+		{v: &struct {
+			N map[int]string `conf:"n"`
+		}{}, err: errType},
+		{v: &struct {
+			N map[string]int `conf:"n,dup=never"`
+		}{}, err: errNotValid},
+		{v: &struct {
+			N []int `conf:"n,default='1,x'"`
+		}{}, err: errType},
+		{v: &struct {
+			N time.Month `conf:"n"`
+		}{}, err: errType},
+		{v: &struct {
+			N int `conf:"n,default='1"`
+		}{}, err: errNotValid},
+		{v: &struct {
+			P int `conf:"p,defualt=8080,cmds=nope"`
+		}{}, err: errNotValid},
+	}
+	for _, test := range tests {
+		config, _ := testConfig("serve", "run", "remote", "remote add")
+		_, err := config.Bind(test.v)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: %T: %s", fname, test.v, err)
+		}
+	}
+
+	// An unknown key is named along with its field.
+	config, _ := testConfig()
+	_, err := config.Bind(&struct {
+		Port int `conf:"p,defualt=8080"`
+	}{})
+	if err == nil || !strings.Contains(err.Error(), `Port: parseTag: "defualt"`) {
+		t.Errorf("%s: received %v", fname, err)
+	}
+}
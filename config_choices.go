@@ -0,0 +1,184 @@
+package conf
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Choices
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// elementer is implemented by those flag values that hold a list,
+// elements returning the string form of each of the values.
+type elementer interface {
+	elements() []string
+}
+
+// choiceValue wraps the flag.Value of an option that has Choices,
+// rejecting any value that is not one of them, whatever its source.
+type choiceValue struct {
+	flag.Value
+	choices []string
+}
+
+func (v *choiceValue) Set(s string) error {
+	if _, ok := v.Value.(elementer); !ok {
+		if err := isChoice(s, v.choices); err != nil {
+			return err
+		}
+		return v.Value.Set(s)
+	}
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+	return v.check()
+}
+
+func (v *choiceValue) String() string {
+	if v == nil || v.Value == nil {
+		return ""
+	}
+	return v.Value.String()
+}
+
+// IsBoolFlag forwards that of the wrapped value, such that a boolean
+// flag with choices may be given without a value.
+func (v *choiceValue) IsBoolFlag() bool {
+	b, ok := v.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func (v *choiceValue) setList(elements []string) error {
+	l, ok := v.Value.(lister)
+	if !ok {
+		return fmt.Errorf("not a list: %w", errType)
+	}
+	if err := l.setList(elements); err != nil {
+		return err
+	}
+	return v.check()
+}
+
+func (v *choiceValue) reset() {
+	if r, ok := v.Value.(resetter); ok {
+		r.reset()
+	}
+}
+
+// values returns the current value, or the elements of a list.
+func (v *choiceValue) values() []string {
+	if e, ok := v.Value.(elementer); ok {
+		return e.elements()
+	}
+	return []string{v.Value.String()}
+}
+
+// check returns an error if the current value, or any one of the
+// elements of a list, is not one of the choices.
+func (v *choiceValue) check() error {
+	for _, s := range v.values() {
+		if err := isChoice(s, v.choices); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChoices checks that the options type permits choices.
+func checkChoices(o Option) error {
+	const fname = "checkChoices"
+	if len(o.Choices) == 0 {
+		return nil
+	}
+	switch {
+	case o.Type == Bool, o.Type == BoolVar, isMap(o.Type):
+		return fmt.Errorf("%s: %s: choices not permitted: %w",
+			fname, o.Type, errType)
+	}
+	return nil
+}
+
+// choiceFlag wraps the options flag such that only its choices are
+// accepted, its current value, the default, must itself be one of
+// them. The choices are added to the flags usage.
+func choiceFlag(c *Config, o *Option) error {
+	const fname = "choiceFlag"
+	f := c.flagSet.Lookup(o.Flag)
+	if f == nil {
+		return fmt.Errorf("%s: %s: %w", fname, o.Flag, errNoFlag)
+	}
+	v := &choiceValue{Value: f.Value, choices: o.Choices}
+	if err := v.check(); err != nil {
+		return fmt.Errorf("%s: %s: Default: %w", fname, o.Flag, err)
+	}
+	f.Value = v
+	f.Usage += "\n" + choicesUsage(o.Choices, v.values())
+	return nil
+}
+
+// choicesUsage returns the list of choices for the usage output, those
+// that are the default being marked.
+func choicesUsage(choices, def []string) string {
+	s := make([]string, len(choices))
+	for i, choice := range choices {
+		s[i] = choice
+		for _, d := range def {
+			if choice == d {
+				s[i] += " (default)"
+				break
+			}
+		}
+	}
+	return "one of: " + strings.Join(s, ", ")
+}
+
+// isChoice returns nil if s is one of the choices, else an error
+// suggesting the nearest of them.
+func isChoice(s string, choices []string) error {
+	best, min := "", -1
+	for _, choice := range choices {
+		if s == choice {
+			return nil
+		}
+		if d := distance(s, choice); min < 0 || d < min {
+			best, min = choice, d
+		}
+	}
+	return fmt.Errorf("%q: did you mean %q? %w", s, best, ErrChoice)
+}
+
+// distance returns the edit distance between two strings, the number of
+// single character insertions, deletions or substitutions required to
+// transform one into the other.
+func distance(a, b string) int {
+	r, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(r); i++ {
+		cur := make([]int, len(t)+1)
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if r[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(t)]
+}
+
+func minInt(n ...int) int {
+	m := n[0]
+	for _, i := range n[1:] {
+		if i < m {
+			m = i
+		}
+	}
+	return m
+}
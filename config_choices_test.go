@@ -0,0 +1,145 @@
+package conf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChoices(t *testing.T) {
+	const fname = "TestChoices"
+	tests := []struct {
+		args   []string
+		env    string
+		format string
+		err    error
+	}{
+		{args: []string{}, format: "text"},
+		{args: []string{"-format", "json", "-tag", "b,c"}, format: "json"},
+		{args: []string{}, env: "yaml", format: "yaml"},
+		{args: []string{}, env: "yml", err: errConfig},
+	}
+	for _, test := range tests {
+		if test.env != "" {
+			t.Setenv("APP_FORMAT", test.env)
+		}
+		config, cmd := testConfig()
+		config.EnvPrefix("app")
+		opts := []Option{
+			{Type: String, Flag: "format", Default: "text",
+				Choices: []string{"json", "text", "yaml"}, Commands: cmd[0]},
+			{Type: StringSlice, Flag: "tag", Default: []string{"a"},
+				Choices: []string{"a", "b", "c"}, Commands: cmd[0]},
+		}
+		_, err := config.ComposeArgs(test.args, opts...)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: %v: received %v expected %v",
+				fname, test.args, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if s, _ := config.ValueString("format"); s != test.format {
+			t.Errorf("%s: %v: received %q expected %q",
+				fname, test.args, s, test.format)
+		}
+	}
+}
+
+// autoValue is a boolean flag that may also be set to auto.
+type autoValue struct {
+	s string
+}
+
+func (v *autoValue) String() string     { return v.s }
+func (v *autoValue) Set(s string) error { v.s = s; return nil }
+func (v *autoValue) IsBoolFlag() bool   { return true }
+
+func TestChoicesBool(t *testing.T) {
+	const fname = "TestChoicesBool"
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{}, want: "false"},
+		{args: []string{"-color", "arg"}, want: "true"},
+		{args: []string{"-color=auto"}, want: "auto"},
+	}
+	for _, test := range tests {
+		config, cmd := testConfig()
+		v := &autoValue{s: "false"}
+		opts := []Option{
+			{Type: Var, Flag: "color", Value: v,
+				Choices: []string{"true", "false", "auto"}, Commands: cmd[0]},
+		}
+		if _, err := config.ComposeArgs(test.args, opts...); err != nil {
+			t.Errorf("%s: %v: %s", fname, test.args, err)
+			continue
+		}
+		if v.s != test.want {
+			t.Errorf("%s: %v: received %q expected %q",
+				fname, test.args, v.s, test.want)
+		}
+	}
+}
+
+func TestChoicesDefault(t *testing.T) {
+	const fname = "TestChoicesDefault"
+	config, cmd := testConfig()
+	opts := []Option{
+		{Type: String, Flag: "format", Default: "xml",
+			Choices: []string{"json", "text"}, Commands: cmd[0]},
+	}
+	_, err := config.ComposeArgs([]string{}, opts...)
+	if !errors.Is(err, errConfig) || !strings.Contains(err.Error(), ErrChoice.Error()) {
+		t.Errorf("%s: received %v expected %v", fname, err, ErrChoice)
+	}
+	config, cmd = testConfig()
+	opts = []Option{
+		{Type: Bool, Flag: "b", Default: false,
+			Choices: []string{"true"}, Commands: cmd[0]},
+	}
+	if _, err := config.ComposeArgs([]string{}, opts...); !errors.Is(err, errConfig) {
+		t.Errorf("%s: received %v expected %v", fname, err, errConfig)
+	}
+}
+
+func TestChoicesUsage(t *testing.T) {
+	const fname = "TestChoicesUsage"
+	config, cmd := testConfig()
+	opts := []Option{
+		{Type: String, Flag: "format", Default: "text",
+			Choices: []string{"json", "text", "yaml"}, Commands: cmd[0]},
+	}
+	if _, err := config.ComposeArgs([]string{}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	usage := config.flagSet.Lookup("format").Usage
+	want := "one of: json, text (default), yaml"
+	if !strings.HasSuffix(usage, want) {
+		t.Errorf("%s: received %q expected %q", fname, usage, want)
+	}
+}
+
+func TestIsChoice(t *testing.T) {
+	const fname = "TestIsChoice"
+	choices := []string{"json", "text", "yaml"}
+	tests := []struct {
+		s, want string
+	}{
+		{s: "jsn", want: `did you mean "json"?`},
+		{s: "yml", want: `did you mean "yaml"?`},
+		{s: "txt", want: `did you mean "text"?`},
+	}
+	for _, test := range tests {
+		err := isChoice(test.s, choices)
+		if !errors.Is(err, ErrChoice) || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: %q: received %v expected %q",
+				fname, test.s, err, test.want)
+		}
+	}
+	if err := isChoice("text", choices); err != nil {
+		t.Errorf("%s: received %v expected nil", fname, err)
+	}
+}
@@ -0,0 +1,324 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Completion
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// completeCmd is the hidden command through which the completion
+// scripts request their candidates, it is never listed in the usage.
+//
+//	app __complete remote a
+const completeCmd = "__complete"
+
+// Completion writes a completion script for the named shell, one of
+// bash, zsh or fish, to w. The script completes by calling the program
+// with the hidden __complete command, such that the candidates are
+// always those of the running binary. No command is registered to write
+// the script, the program calling Completion from one of its own.
+//
+//	comp := c.Command("completion", "completion [bash|zsh|fish]")
+//	c.Handle(comp, func(ctx context.Context, c *conf.Config, args []string) error {
+//		if len(args) != 1 {
+//			return errors.New("completion: a shell must be named")
+//		}
+//		return c.Completion(args[0], os.Stdout)
+//	})
+func (c *Config) Completion(shell string, w io.Writer) error {
+	const fname = "Config.Completion"
+	app := progName(c)
+	fn := "_" + strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+			r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, app) + "_complete"
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		return fmt.Errorf("%s: %q: unknown shell: %w", fname, shell, errNotValid)
+	}
+	r := strings.NewReplacer("{{app}}", app, "{{fn}}", fn,
+		"{{cmd}}", completeCmd)
+	if _, err := io.WriteString(w, r.Replace(script)); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	return nil
+}
+
+const bashCompletion = `# bash completion for {{app}}
+{{fn}}() {
+	# The words are taken from the line, as COMP_WORDS is also split at
+	# the characters of COMP_WORDBREAKS, such as the = of --flag=value.
+	local IFS=$'\n' line="${COMP_LINE:0:COMP_POINT}" cur pre
+	local -a words out
+	IFS=$' \t\n' read -ra words <<< "$line"
+	if [[ ${#words[@]} -lt 2 || $line == *[[:space:]] ]]; then
+		words+=("")
+	fi
+	cur="${words[${#words[@]}-1]}"
+	out=($("${words[0]}" {{cmd}} "${words[@]:1}" 2>/dev/null))
+	case "${out[0]}" in
+	:file)
+		compopt -o filenames
+		COMPREPLY=($(compgen -f -- "$cur")) ;;
+	:dir)
+		compopt -o filenames
+		COMPREPLY=($(compgen -d -- "$cur")) ;;
+	*)
+		COMPREPLY=("${out[@]}") ;;
+	esac
+	# Bash replaces the text that follows the last word break alone.
+	pre="${cur%"${cur##*[=:]}"}"
+	if [[ -n $pre && $COMP_WORDBREAKS == *"${pre: -1}"* ]]; then
+		COMPREPLY=("${COMPREPLY[@]#"$pre"}")
+	fi
+}
+complete -F {{fn}} {{app}}
+`
+
+const zshCompletion = `#compdef {{app}}
+# zsh completion for {{app}}
+{{fn}}() {
+	local -a out
+	out=(${(f)"$("${words[1]}" {{cmd}} "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+	case "${out[1]}" in
+	:file) _files ;;
+	:dir) _files -/ ;;
+	*) compadd -Q -- "${out[@]}" ;;
+	esac
+}
+compdef {{fn}} {{app}}
+`
+
+const fishCompletion = `# fish completion for {{app}}
+function {{fn}}
+	set -l args (commandline -opc)
+	set -e args[1]
+	set -l out ({{app}} {{cmd}} $args (commandline -ct) 2>/dev/null)
+	switch "$out[1]"
+	case :file
+		__fish_complete_path (commandline -ct)
+	case :dir
+		__fish_complete_directories (commandline -ct)
+	case '*'
+		printf '%s\n' $out
+	end
+end
+complete -c {{app}} -f -a '({{fn}})'
+`
+
+// CompleteHint is the shell completion of a value that has no other
+// candidates, it is written by the __complete command as a directive
+// in place of the candidates.
+type CompleteHint int
+
+const (
+	// HintDefault completes file names for the string types that
+	// have neither choices nor a Complete function, and nothing for
+	// all others.
+	HintDefault CompleteHint = iota
+	// HintFile completes file names.
+	HintFile
+	// HintDir completes directory names.
+	HintDir
+	// HintNone completes nothing.
+	HintNone
+)
+
+func (h CompleteHint) String() string {
+	switch h {
+	case HintDefault:
+		return "default"
+	case HintFile:
+		return "file"
+	case HintDir:
+		return "dir"
+	case HintNone:
+		return "none"
+	default:
+		return "error: unknown hint"
+	}
+}
+
+// runComplete writes the completion candidates for the given words, the
+// arguments that follow the hidden command, the last of them being the
+// word under completion, one per line; When there are none a file or
+// directory hint may be written instead, as the directive :file or
+// :dir. The Config's error handling is then applied, ErrComplete being
+// returned when it continues on error.
+func runComplete(c *Config, words []string, opts ...Option) error {
+	const fname = "runComplete"
+	if err := loadOptions(c, opts...); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	w := stdout(c)
+	out, hint := complete(c, words)
+	for _, s := range out {
+		fmt.Fprintln(w, s)
+	}
+	if len(out) == 0 && (hint == HintFile || hint == HintDir) {
+		fmt.Fprintf(w, ":%s\n", hint)
+	}
+
+	if v2() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	switch c.handling {
+	case ExitOnError:
+		os.Exit(ExitOK)
+	case PanicOnError:
+		panic(ErrComplete)
+	}
+	return ErrComplete
+}
+
+// complete returns the candidates for the last of the words; Command
+// names whilst every preceding word is a command, the flags of the
+// command otherwise, or the values of a flag that awaits its value. The
+// hint is that which applies when there are no candidates.
+func complete(c *Config, words []string) ([]string, CompleteHint) {
+	cur := ""
+	if len(words) > 0 {
+		cur, words = words[len(words)-1], words[:len(words)-1]
+	}
+
+	// Walk the command tree in the same manner as ascertainCmdSet.
+	m, walking := 0, true
+	for _, arg := range words {
+		if len(arg) == 0 || arg[0] == '-' {
+			walking = false
+			break
+		}
+		i, err := findCommand(c, m, arg)
+		if err != nil {
+			walking = false
+			break
+		}
+		m = i
+	}
+	set := &c.commands[m]
+
+	// A flag awaiting its value, either as the previous word or
+	// within the current word.
+	if len(words) > 0 {
+		if o := completeFlag(set, words[len(words)-1]); o != nil &&
+			!isBoolOption(o) {
+			return completeValue(c, m, words[:len(words)-1], o, "", cur)
+		}
+	}
+	if i := strings.IndexByte(cur, '='); i > 0 && cur[0] == '-' {
+		if o := completeFlag(set, cur[:i]); o != nil {
+			return completeValue(c, m, words, o, cur[:i+1], cur[i+1:])
+		}
+		return nil, HintNone
+	}
+
+	var out []string
+	if len(cur) > 0 && cur[0] == '-' {
+		dash := "-"
+		if strings.HasPrefix(cur, "--") {
+			dash = "--"
+		}
+		for _, o := range set.options {
+			if s := dash + o.Flag; strings.HasPrefix(s, cur) {
+				out = append(out, s)
+			}
+		}
+		return out, HintNone
+	}
+	if walking {
+		for _, i := range children(c, m) {
+			if s := c.commands[i].cmd; strings.HasPrefix(s, cur) {
+				out = append(out, s)
+			}
+		}
+	}
+	// Otherwise an argument.
+	return out, HintFile
+}
+
+// completeFlag returns the option of the command that is named by the
+// word as a flag, nil if the word is not one of its flags.
+func completeFlag(m *command, word string) *Option {
+	if strings.HasPrefix(word, "--") {
+		word = word[2:]
+	} else if strings.HasPrefix(word, "-") {
+		word = word[1:]
+	} else {
+		return nil
+	}
+	if word == "" || strings.ContainsRune(word, '=') {
+		return nil
+	}
+	return m.options.find(word)
+}
+
+// completeValue returns the candidates for the options value that
+// begin with prefix, each preceded by lead; Those of its Complete
+// function, which is given the Config as composed from the preceding
+// words, or else its choices.
+func completeValue(c *Config, m int, words []string, o *Option, lead, prefix string) ([]string, CompleteHint) {
+	values := o.Choices
+	if o.Complete != nil {
+		completeCompose(c, m, words)
+		values = o.Complete(prefix, c)
+	}
+	var out []string
+	for _, s := range values {
+		if strings.HasPrefix(s, prefix) {
+			out = append(out, lead+s)
+		}
+	}
+	if lead != "" {
+		// The shell can not complete a file name within the word.
+		return out, HintNone
+	}
+	if o.Hint == HintDefault {
+		if o.Complete == nil && len(o.Choices) == 0 && (isString(o.Type) ||
+			o.Type == StringSlice || o.Type == StringSliceVar) {
+			return out, HintFile
+		}
+		return out, HintNone
+	}
+	return out, o.Hint
+}
+
+// completeCompose composes the Config for the command at index m from
+// the given words, such that a Complete function may read the values
+// that have been given so far; Errors are ignored, parsing stopping at
+// the first of them.
+func completeCompose(c *Config, m int, words []string) {
+	c.set = &c.commands[m]
+	handling, out, errs := c.handling, c.stdout, c.stderr
+	c.handling, c.stdout, c.stderr = ContinueOnError, io.Discard, io.Discard
+	err := setupFlagSet(c, words)
+	c.handling, c.stdout, c.stderr = handling, out, errs
+	if err != nil && v2() {
+		log.Printf("completeCompose: %s\n", err)
+	}
+}
+
+// isBoolOption returns true if the options flag takes no value.
+func isBoolOption(o *Option) bool {
+	if o.Type == Bool || o.Type == BoolVar {
+		return true
+	}
+	b, ok := o.Value.(interface{ IsBoolFlag() bool })
+	return o.Type == Var && ok && b.IsBoolFlag()
+}
@@ -0,0 +1,129 @@
+package conf
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	const fname = "TestComplete"
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{words: []string{""}, want: []string{"remote", "run"}},
+		{words: []string{"r"}, want: []string{"remote", "run"}},
+		{words: []string{"re"}, want: []string{"remote"}},
+		{words: []string{"remote", ""}, want: []string{"add", "rm"}},
+		{words: []string{"remote", "add", "-"}, want: []string{"-name"}},
+		{words: []string{"-"}, want: []string{"-n", "-format", "-v"}},
+		{words: []string{"--f"}, want: []string{"--format"}},
+		{words: []string{"-format", ""}, want: []string{"text", "json", "yaml"}},
+		{words: []string{"-format", "j"}, want: []string{"json"}},
+		{words: []string{"-format=y"}, want: []string{"-format=yaml"}},
+		{words: []string{"-v", ""}, want: []string{":file"}},
+		{words: []string{"-n", "2", ""}, want: []string{":file"}},
+		{words: []string{"-n", ""}},
+		{words: []string{"remot", ""}, want: []string{":file"}},
+		{words: []string{"remote", "add", "-name", ""}, want: []string{":file"}},
+		{words: []string{"-x"}},
+		{words: []string{}, want: []string{"remote", "run"}},
+	}
+	for _, test := range tests {
+		config, cmd := testConfig("remote", "remote add", "remote rm", "run")
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 1, Commands: cmd[0]},
+			{Type: String, Flag: "format", Default: "text",
+				Choices: []string{"text", "json", "yaml"}, Commands: cmd[0]},
+			{Type: Bool, Flag: "v", Default: false, Commands: cmd[0]},
+			{Type: String, Flag: "name", Default: "", Commands: cmd[2]},
+		}
+		var out bytes.Buffer
+		config.SetOutput(&out, &out)
+		config.SetErrorHandling(ContinueOnError)
+		args := append([]string{completeCmd}, test.words...)
+		if _, err := config.ComposeArgs(args, opts...); !errors.Is(err, ErrComplete) {
+			t.Errorf("%s: %q: received %v expected %v",
+				fname, test.words, err, ErrComplete)
+			continue
+		}
+		got := strings.Fields(out.String())
+		if len(got) == 0 {
+			got = nil
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: %q: received %q expected %q",
+				fname, test.words, got, test.want)
+		}
+	}
+}
+
+func TestCompleteFunc(t *testing.T) {
+	const fname = "TestCompleteFunc"
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{words: []string{"-branch", ""}, want: []string{"dev", "main"}},
+		{words: []string{"-repo", "b", "-branch", ""}, want: []string{"fix"}},
+		{words: []string{"-repo", "b", "-branch", "x"}},
+		{words: []string{"-branch=m"}, want: []string{"-branch=main"}},
+		{words: []string{"-dir", ""}, want: []string{":dir"}},
+		{words: []string{"-repo", ""}},
+	}
+	branches := map[string][]string{"a": {"dev", "main"}, "b": {"fix"}}
+	for _, test := range tests {
+		config := &Config{}
+		def := config.Command("Usage heading", "")
+		opts := []Option{
+			{Type: String, Flag: "repo", Default: "a", Hint: HintNone,
+				Commands: def},
+			{Type: String, Flag: "branch", Default: "", Commands: def,
+				Complete: func(prefix string, c *Config) []string {
+					repo, _ := c.ValueString("repo")
+					return branches[repo]
+				}},
+			{Type: String, Flag: "dir", Default: "", Hint: HintDir,
+				Commands: def},
+		}
+		var out bytes.Buffer
+		config.SetOutput(&out, &out)
+		config.SetErrorHandling(ContinueOnError)
+		args := append([]string{completeCmd}, test.words...)
+		if _, err := config.ComposeArgs(args, opts...); !errors.Is(err, ErrComplete) {
+			t.Errorf("%s: %q: received %v expected %v",
+				fname, test.words, err, ErrComplete)
+			continue
+		}
+		got := strings.Fields(out.String())
+		if len(got) == 0 {
+			got = nil
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: %q: received %q expected %q",
+				fname, test.words, got, test.want)
+		}
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	const fname = "TestCompletion"
+	config, _ := testConfig()
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var buf bytes.Buffer
+		if err := config.Completion(shell, &buf); err != nil {
+			t.Errorf("%s: %s: %s", fname, shell, err)
+			continue
+		}
+		if !strings.Contains(buf.String(), completeCmd) ||
+			strings.Contains(buf.String(), "{{") {
+			t.Errorf("%s: %s: received %q", fname, shell, buf.String())
+		}
+	}
+	if err := config.Completion("csh", &bytes.Buffer{}); !errors.Is(err, errNotValid) {
+		t.Errorf("%s: received %v expected %v", fname, err, errNotValid)
+	}
+}
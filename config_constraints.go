@@ -0,0 +1,336 @@
+package conf
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Constraints
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// The rules that may be named by a ConstraintError.
+const (
+	RuleMin      = "min"
+	RuleMax      = "max"
+	RulePattern  = "pattern"
+	RuleMinLen   = "minlen"
+	RuleMaxLen   = "maxlen"
+	RuleNonEmpty = "nonempty"
+)
+
+// ConstraintError is the error raised when the value of an option
+// violates one of its constraints, once the flagset has been parsed.
+type ConstraintError struct {
+	// Flag is the flag of the option.
+	Flag string
+	// Rule is the violated rule, one of the Rule constants.
+	Rule string
+	// Limit is the rules limit in its string form, empty for the
+	// nonempty rule.
+	Limit string
+	// Value is the offending value in its string form.
+	Value string
+}
+
+func (e *ConstraintError) Error() string {
+	if e.Limit == "" {
+		return fmt.Sprintf("-%s: %q: violates %s", e.Flag, e.Value, e.Rule)
+	}
+	return fmt.Sprintf("-%s: %q: violates %s %s",
+		e.Flag, e.Value, e.Rule, e.Limit)
+}
+
+// Unwrap returns ErrConstraint, such that every ConstraintError may be
+// identified by errors.Is.
+func (e *ConstraintError) Unwrap() error {
+	return ErrConstraint
+}
+
+// ConstraintErrors is the error returned by Compose when the values of
+// options violate their constraints, holding a ConstraintError for each
+// of the options.
+type ConstraintErrors []*ConstraintError
+
+func (e ConstraintErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Unwrap returns every ConstraintError, such that each may be found by
+// errors.As and all of them identified as ErrConstraint by errors.Is.
+func (e ConstraintErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// checkConstraints verifies the constraints of an option against its
+// type, Min and Max must be of the same type as the options Default and
+// the length constraints apply only to strings, slices and maps.
+func checkConstraints(o *Option) error {
+	const fname = "checkConstraints"
+	if o.Min != nil || o.Max != nil {
+		if !isNumeric(o.Type) {
+			return fmt.Errorf("%s: %s: Min Max: %w", fname, o.Type, errType)
+		}
+		for _, v := range []interface{}{o.Min, o.Max} {
+			if v != nil && reflect.TypeOf(v) != reflect.TypeOf(o.Default) {
+				return fmt.Errorf("%s: %s: %T: %w",
+					fname, o.Type, v, errType)
+			}
+		}
+		if o.Min != nil && o.Max != nil {
+			if c, _ := compare(o.Min, o.Max); c > 0 {
+				return fmt.Errorf("%s: Min exceeds Max: %w",
+					fname, errNotValid)
+			}
+		}
+	}
+	if o.MinLen != 0 || o.MaxLen != 0 || o.NonEmpty {
+		if !isString(o.Type) && !isSlice(o.Type) && !isMap(o.Type) {
+			return fmt.Errorf("%s: %s: length: %w", fname, o.Type, errType)
+		}
+	}
+	if o.Pattern != "" {
+		if !isString(o.Type) && o.Type != StringSlice &&
+			o.Type != StringSliceVar {
+			return fmt.Errorf("%s: %s: Pattern: %w",
+				fname, o.Type, errType)
+		}
+		re, err := regexp.Compile(o.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", fname, err, errNotValid)
+		}
+		o.pattern = re
+	}
+	return nil
+}
+
+// hasConstraints returns true if any constraint is set on the option.
+func hasConstraints(o *Option) bool {
+	return o.Min != nil || o.Max != nil || o.Pattern != "" ||
+		o.MinLen != 0 || o.MaxLen != 0 || o.NonEmpty
+}
+
+// constraintFlag adds the options constraints to its flags usage.
+func constraintFlag(c *Config, o *Option) error {
+	const fname = "constraintFlag"
+	f := c.flagSet.Lookup(o.Flag)
+	if f == nil {
+		return fmt.Errorf("%s: %s: %w", fname, o.Flag, errNoFlag)
+	}
+	f.Usage += "\n" + constraintsUsage(o)
+	return nil
+}
+
+// constraintsUsage returns the options constraints for the usage
+// output.
+func constraintsUsage(o *Option) string {
+	var s []string
+	if o.Min != nil {
+		s = append(s, RuleMin+" "+fmt.Sprint(o.Min))
+	}
+	if o.Max != nil {
+		s = append(s, RuleMax+" "+fmt.Sprint(o.Max))
+	}
+	if o.MinLen != 0 {
+		s = append(s, fmt.Sprintf("%s %d", RuleMinLen, o.MinLen))
+	}
+	if o.MaxLen != 0 {
+		s = append(s, fmt.Sprintf("%s %d", RuleMaxLen, o.MaxLen))
+	}
+	if o.NonEmpty {
+		s = append(s, RuleNonEmpty)
+	}
+	if o.Pattern != "" {
+		s = append(s, RulePattern+" "+o.Pattern)
+	}
+	return "limits: " + strings.Join(s, ", ")
+}
+
+// runConstraints checks the value of every option in the running
+// command set against its constraints, all violations are accumulated
+// into ConstraintErrors before returning.
+func runConstraints(c *Config) error {
+	const fname = "runConstraints"
+	var errs ConstraintErrors
+	for _, o := range c.set.options {
+		if !hasConstraints(o) {
+			continue
+		}
+		if err := constrain(o); err != nil {
+			o.err = err
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		c.errs = errs
+		return fmt.Errorf("%s: %w", fname, errs)
+	}
+
+	if v2() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return nil
+}
+
+// constrain returns a ConstraintError for the first of the options
+// constraints that its current value violates.
+func constrain(o *Option) *ConstraintError {
+	v := optionValue(o)
+	fail := func(rule, limit string) *ConstraintError {
+		return &ConstraintError{Flag: o.Flag, Rule: rule, Limit: limit,
+			Value: valueString(v)}
+	}
+	if o.Min != nil {
+		if c, ok := compare(v, o.Min); ok && c < 0 {
+			return fail(RuleMin, fmt.Sprint(o.Min))
+		}
+	}
+	if o.Max != nil {
+		if c, ok := compare(v, o.Max); ok && c > 0 {
+			return fail(RuleMax, fmt.Sprint(o.Max))
+		}
+	}
+	n := length(v)
+	if o.NonEmpty && n == 0 {
+		return fail(RuleNonEmpty, "")
+	}
+	if o.MinLen != 0 && n < o.MinLen {
+		return fail(RuleMinLen, fmt.Sprint(o.MinLen))
+	}
+	if o.MaxLen != 0 && n > o.MaxLen {
+		return fail(RuleMaxLen, fmt.Sprint(o.MaxLen))
+	}
+	if o.pattern != nil {
+		values, _ := v.([]string)
+		if s, ok := v.(string); ok {
+			values = []string{s}
+		}
+		for _, s := range values {
+			if !o.pattern.MatchString(s) {
+				return &ConstraintError{Flag: o.Flag, Rule: RulePattern,
+					Limit: o.Pattern, Value: s}
+			}
+		}
+	}
+	return nil
+}
+
+// optionValue returns the current value of an option.
+func optionValue(o *Option) interface{} {
+	if o.Type == Var {
+		return o.Value
+	}
+	p := o.data
+	if p == nil {
+		p = o.Var
+	}
+	rv := reflect.ValueOf(p)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil
+	}
+	return rv.Elem().Interface()
+}
+
+// valueString returns the string form of a value, the elements of a
+// slice being separated by commas, as are the sorted key=value pairs of
+// a map.
+func valueString(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		s := make([]string, rv.Len())
+		for i := range s {
+			s[i] = fmt.Sprint(rv.Index(i).Interface())
+		}
+		return strings.Join(s, ",")
+	case reflect.Map:
+		s := make([]string, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			s = append(s, fmt.Sprintf("%v=%v",
+				iter.Key().Interface(), iter.Value().Interface()))
+		}
+		sort.Strings(s)
+		return strings.Join(s, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// length returns the number of characters in a string, or the number of
+// elements in a slice or map.
+func length(v interface{}) int {
+	if s, ok := v.(string); ok {
+		return utf8.RuneCountInString(s)
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len()
+	}
+	return 0
+}
+
+// compare returns -1, 0 or 1 as a is less than, equal to or greater than
+// b, false if the values are not of the same numeric type.
+func compare(a, b interface{}) (int, bool) {
+	switch a := a.(type) {
+	case int:
+		b, ok := b.(int)
+		return order(a, b), ok
+	case int64:
+		b, ok := b.(int64)
+		return order(a, b), ok
+	case uint:
+		b, ok := b.(uint)
+		return order(a, b), ok
+	case uint64:
+		b, ok := b.(uint64)
+		return order(a, b), ok
+	case float64:
+		b, ok := b.(float64)
+		return order(a, b), ok
+	case time.Duration:
+		b, ok := b.(time.Duration)
+		return order(a, b), ok
+	}
+	return 0, false
+}
+
+func order[T int | int64 | uint | uint64 | float64 | time.Duration](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// isNumeric returns true if the type is one of the numeric types.
+func isNumeric(t Type) bool {
+	switch t {
+	case Int, IntVar, Int64, Int64Var, Uint, UintVar, Uint64, Uint64Var,
+		Float64, Float64Var, Duration, DurationVar:
+		return true
+	}
+	return false
+}
+
+// isString returns true if the type is one of the string types.
+func isString(t Type) bool {
+	return t == String || t == StringVar
+}
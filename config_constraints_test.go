@@ -0,0 +1,116 @@
+package conf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConstraints(t *testing.T) {
+	const fname = "TestConstraints"
+	tests := []struct {
+		args []string
+		flag string
+		rule string
+		also string
+	}{
+		{args: []string{}},
+		{args: []string{"-port", "1", "-name", "ab", "-wait", "1m"}},
+		{args: []string{"-port", "0"}, flag: "port", rule: RuleMin},
+		{args: []string{"-port", "0", "-name", "A"}, flag: "port",
+			rule: RuleMin, also: "name"},
+		{args: []string{"-port", "65536"}, flag: "port", rule: RuleMax},
+		{args: []string{"-wait", "2m"}, flag: "wait", rule: RuleMax},
+		{args: []string{"-name", "a"}, flag: "name", rule: RuleMinLen},
+		{args: []string{"-name", "abcdefghi"}, flag: "name", rule: RuleMaxLen},
+		{args: []string{"-name", "AB"}, flag: "name", rule: RulePattern},
+		{args: []string{"-tag", ""}, flag: "tag", rule: RuleNonEmpty},
+	}
+	usage := map[string]string{
+		"port": "limits: min 1, max 65535",
+		"wait": "limits: max 1m0s",
+		"name": "limits: minlen 2, maxlen 8, pattern ^[a-z]+$",
+		"tag":  "limits: nonempty",
+	}
+	for _, test := range tests {
+		config, cmd := testConfig()
+		opts := []Option{
+			{Type: Int, Flag: "port", Default: 8080, Min: 1, Max: 65535,
+				Commands: cmd[0]},
+			{Type: Duration, Flag: "wait", Default: time.Second,
+				Max: time.Minute, Commands: cmd[0]},
+			{Type: String, Flag: "name", Default: "abc", Pattern: "^[a-z]+$",
+				MinLen: 2, MaxLen: 8, Commands: cmd[0]},
+			{Type: StringSlice, Flag: "tag", Default: []string{"a"},
+				NonEmpty: true, Commands: cmd[0]},
+		}
+		_, err := config.ComposeArgs(test.args, opts...)
+		if test.rule == "" {
+			if err != nil {
+				t.Errorf("%s: %v: %s", fname, test.args, err)
+				continue
+			}
+			for flag, want := range usage {
+				u := config.flagSet.Lookup(flag).Usage
+				if !strings.HasSuffix(u, want) {
+					t.Errorf("%s: received %q expected %q", fname, u, want)
+				}
+			}
+			continue
+		}
+		// All violations are reported together, each being found.
+		var errs ConstraintErrors
+		if !errors.As(err, &errs) {
+			t.Errorf("%s: %v: received %v expected %T",
+				fname, test.args, err, errs)
+			continue
+		}
+		var flags []string
+		for _, e := range errs {
+			if !errors.Is(err, e) {
+				t.Errorf("%s: %v: %v not found", fname, test.args, e)
+			}
+			flags = append(flags, e.Flag)
+		}
+		want := strings.TrimSpace(test.flag + " " + test.also)
+		if strings.Join(flags, " ") != want {
+			t.Errorf("%s: %v: received %v expected %s",
+				fname, test.args, flags, want)
+		}
+		var ce *ConstraintError
+		if !errors.As(err, &ce) || !errors.Is(err, ErrConstraint) {
+			t.Errorf("%s: %v: received %v expected %v",
+				fname, test.args, err, ErrConstraint)
+			continue
+		}
+		if ce.Flag != test.flag || ce.Rule != test.rule {
+			t.Errorf("%s: %v: received %s %s expected %s %s", fname,
+				test.args, ce.Flag, ce.Rule, test.flag, test.rule)
+		}
+		if _, _, err := config.Value(test.flag); !errors.Is(err, ErrConstraint) {
+			t.Errorf("%s: %v: received %v expected %v",
+				fname, test.args, err, ErrConstraint)
+		}
+	}
+}
+
+func TestConstraintsInvalid(t *testing.T) {
+	const fname = "TestConstraintsInvalid"
+	tests := []Option{
+		{Type: Int, Flag: "n", Default: 1, Min: int64(0)},
+		{Type: Int, Flag: "n", Default: 1, Min: 2, Max: 1},
+		{Type: String, Flag: "s", Default: "", Min: ""},
+		{Type: Int, Flag: "n", Default: 1, NonEmpty: true},
+		{Type: Int, Flag: "n", Default: 1, Pattern: "x"},
+		{Type: String, Flag: "s", Default: "", Pattern: "("},
+	}
+	for _, o := range tests {
+		config, cmd := testConfig()
+		o.Commands = cmd[0]
+		if _, err := config.ComposeArgs([]string{}, o); !errors.Is(err, errConfig) {
+			t.Errorf("%s: %+v: received %v expected %v",
+				fname, o, err, errConfig)
+		}
+	}
+}
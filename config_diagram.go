@@ -0,0 +1,160 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Diagram
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// Diagram writes a diagram of the command tree to w, as either plantuml
+// or dot; Each command lists the options that belong to it alone, those
+// that are shared by several commands being drawn apart and linked to
+// each of their commands. The options are found as they are by Man,
+// and the graph of a dot diagram is named after the program.
+//
+//	c.Diagram("plantuml", os.Stdout, opts...)
+func (c *Config) Diagram(format string, w io.Writer, opts ...Option) error {
+	const fname = "Config.Diagram"
+	c, err := docConfig(c, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	var s string
+	switch format {
+	case "plantuml":
+		s = plantumlDiagram(c)
+	case "dot":
+		s = dotDiagram(c)
+	default:
+		return fmt.Errorf("%s: %q: unknown format: %w",
+			fname, format, errNotValid)
+	}
+	if _, err := io.WriteString(w, s); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	return nil
+}
+
+// diagramOwners returns the index within the Config's commands of every
+// command that contains the option.
+func diagramOwners(c *Config, o *Option) []int {
+	var out []int
+	for i := range c.commands {
+		if c.commands[i].options.find(o.Flag) == o {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// diagramField returns the description of an option within a diagram,
+// its flag, type and default.
+func diagramField(o *Option, flag bool) string {
+	s := o.Type.String()
+	if flag {
+		s = "-" + o.Flag + " : " + s
+	}
+	if o.Default != nil {
+		if d := valueString(o.Default); d != "" {
+			s += " = " + d
+		}
+	}
+	return s
+}
+
+// plantumlDiagram returns the plantuml diagram of the command tree.
+func plantumlDiagram(c *Config) string {
+	var b strings.Builder
+	b.WriteString("@startuml\n\nskinparam classAttributeIconSize 0\n" +
+		"hide empty members\n\n")
+	for i := range c.commands {
+		m := &c.commands[i]
+		fmt.Fprintf(&b, "class %q as c%d <<command>>", commandName(c, m), i)
+		var fields []string
+		for _, o := range m.options {
+			if len(diagramOwners(c, o)) == 1 {
+				fields = append(fields, diagramField(o, true))
+			}
+		}
+		if len(fields) > 0 {
+			fmt.Fprintf(&b, " {\n\t%s\n}", strings.Join(fields, "\n\t"))
+		}
+		b.WriteString("\n")
+	}
+	var links []string
+	for i, o := range c.all {
+		owners := diagramOwners(c, o)
+		if len(owners) < 2 {
+			continue
+		}
+		fmt.Fprintf(&b, "class %q as o%d <<shared>> {\n\t%s\n}\n",
+			"-"+o.Flag, i, diagramField(o, false))
+		for _, j := range owners {
+			links = append(links, fmt.Sprintf("c%d o-- o%d", j, i))
+		}
+	}
+	b.WriteString("\n")
+	for i, m := range c.commands {
+		if m.parent >= 0 {
+			fmt.Fprintf(&b, "c%d *-- c%d\n", m.parent, i)
+		}
+	}
+	for _, l := range links {
+		b.WriteString(l + "\n")
+	}
+	b.WriteString("\n@enduml\n")
+	return b.String()
+}
+
+// dotDiagram returns the graphviz dot diagram of the command tree.
+func dotDiagram(c *Config) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n\tnode [shape=record];\n", progName(c))
+	for i := range c.commands {
+		m := &c.commands[i]
+		label := dotEscape(commandName(c, m))
+		var fields []string
+		for _, o := range m.options {
+			if len(diagramOwners(c, o)) == 1 {
+				fields = append(fields, dotEscape(diagramField(o, true))+"\\l")
+			}
+		}
+		if len(fields) > 0 {
+			label += "|" + strings.Join(fields, "")
+		}
+		fmt.Fprintf(&b, "\tc%d [label=\"{%s}\"];\n", i, label)
+	}
+	var links []string
+	for i, o := range c.all {
+		owners := diagramOwners(c, o)
+		if len(owners) < 2 {
+			continue
+		}
+		fmt.Fprintf(&b, "\to%d [label=\"{%s|%s\\l}\", style=dashed];\n",
+			i, dotEscape("-"+o.Flag), dotEscape(diagramField(o, false)))
+		for _, j := range owners {
+			links = append(links,
+				fmt.Sprintf("\tc%d -> o%d [style=dashed];\n", j, i))
+		}
+	}
+	for i, m := range c.commands {
+		if m.parent >= 0 {
+			fmt.Fprintf(&b, "\tc%d -> c%d;\n", m.parent, i)
+		}
+	}
+	b.WriteString(strings.Join(links, ""))
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotEscape escapes the characters that are special within a dot record
+// label.
+func dotEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`,
+		"|", `\|`, "<", `\<`, ">", `\>`)
+	return r.Replace(s)
+}
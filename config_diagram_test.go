@@ -0,0 +1,66 @@
+package conf
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDiagram(t *testing.T) {
+	const fname = "TestDiagram"
+	config, cmd := testConfig("remote", "remote add")
+	config.ProgName("app")
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 12, Commands: cmd[0]},
+		{Type: Bool, Flag: "v", Default: false, Commands: Cmds(cmd[0], cmd[1])},
+		{Type: String, Flag: "name", Default: "origin", Commands: cmd[2]},
+		{Type: StringMap, Flag: "l", Default: map[string]string{"b": "2",
+			"a": "1"}, Commands: cmd[2]},
+	}
+	app := "app"
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: "plantuml", want: []string{
+			"@startuml\n",
+			"class \"" + app + "\" as c0 <<command>> {\n\t-n : int = 12\n}\n",
+			"class \"" + app + " remote\" as c1 <<command>>\n",
+			"class \"" + app + " remote add\" as c2 <<command>> {\n" +
+				"\t-name : string = origin\n\t-l : map[string]string = a=1,b=2\n}\n",
+			"class \"-v\" as o1 <<shared>> {\n\tbool = false\n}\n",
+			"c0 *-- c1\nc1 *-- c2\nc0 o-- o1\nc1 o-- o1\n",
+			"@enduml\n",
+		}},
+		{format: "dot", want: []string{
+			"digraph \"" + app + "\" {\n",
+			"\tc0 [label=\"{" + app + "|-n : int = 12\\l}\"];\n",
+			"\tc1 [label=\"{" + app + " remote}\"];\n",
+			"\to1 [label=\"{-v|bool = false\\l}\", style=dashed];\n",
+			"\tc0 -> c1;\n\tc1 -> c2;\n\tc0 -> o1 [style=dashed];\n\tc1 -> o1 [style=dashed];\n}\n",
+		}},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := config.Diagram(test.format, &buf, opts...); err != nil {
+			t.Errorf("%s: %s: %s", fname, test.format, err)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s: %s: %q not found in %q",
+					fname, test.format, want, buf.String())
+			}
+		}
+	}
+	if err := config.Diagram("svg", &bytes.Buffer{}, opts...); !errors.Is(err, errNotValid) {
+		t.Errorf("%s: received %v expected %v", fname, err, errNotValid)
+	}
+	if err := config.Diagram("dot", &bytes.Buffer{}); !errors.Is(err, errConfig) {
+		t.Errorf("%s: received %v expected %v", fname, err, errConfig)
+	}
+	if s := dotEscape(`a{b|c}<d>"\`); s != `a\{b\|c\}\<d\>\"\\` {
+		t.Errorf("%s: received %s", fname, s)
+	}
+}
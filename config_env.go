@@ -0,0 +1,98 @@
+package conf
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// EnvPrefix sets a prefix from which environment variable names are
+// derived for all options that do not define their own Env field. The
+// variables take the form PREFIX_CMD_FLAG, or PREFIX_FLAG for options
+// of the default command set, all letters are upper cased and any
+// character that is neither a letter nor a digit is replaced by an
+// underscore.
+//
+//	c.EnvPrefix("APP")
+//
+//	APP_WRITE_N=36 app write
+func (c *Config) EnvPrefix(prefix string) {
+	c.envPrefix = prefix
+}
+
+// envName returns the name of the environment variable for the given
+// option in the current running command set, an empty string if there
+// is none.
+func envName(c *Config, o *Option) string {
+	return cmdEnvName(c, c.set, o)
+}
+
+// cmdEnvName returns the name of the environment variable for the given
+// option in the command m, an empty string if there is none.
+func cmdEnvName(c *Config, m *command, o *Option) string {
+	if o.Env != "" {
+		return o.Env
+	}
+	if c.envPrefix == "" {
+		return ""
+	}
+	parts := []string{c.envPrefix}
+	if path := cmdPath(c, m, "_"); path != "" {
+		parts = append(parts, path)
+	}
+	parts = append(parts, o.Flag)
+	return envFormat(strings.Join(parts, "_"))
+}
+
+// envFormat upper cases the name and replaces all characters that are
+// not valid in an environment variable name by underscores.
+func envFormat(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// loadEnv sets the value of every option within the current running
+// command set for which an environment variable has been defined. It is
+// run once the flagset has been created and before it is parsed, such
+// that the environment overrides the option defaults and is itself
+// overridden by the command line.
+func loadEnv(c *Config) error {
+	const fname = "loadEnv"
+	for _, o := range c.set.options {
+		name := envName(c, o)
+		if name == "" {
+			continue
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setFlag(c, o, value); err != nil {
+			storeErr(c, o, fmt.Errorf("%s: %s: %s: %w",
+				fname, o.Flag, name, err))
+			continue
+		}
+		o.src = Source{Kind: FromEnv, Name: name}
+		if v3() {
+			log.Printf("%s: %s: set from %s\n", fname, o.Flag, name)
+		}
+	}
+
+	if c.errs != nil {
+		return fmt.Errorf("%s: %s: %w", fname, c.errs, errConfig)
+	}
+
+	if v2() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return nil
+}
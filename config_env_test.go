@@ -0,0 +1,98 @@
+package conf
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEnvName(t *testing.T) {
+	const fname = "TestEnvName"
+	config, cmd := testConfig("write-out")
+	config.EnvPrefix("app")
+	tests := []struct {
+		set  CMD
+		o    Option
+		want string
+	}{
+		{set: cmd[0], o: Option{Flag: "n"}, want: "APP_N"},
+		{set: cmd[1], o: Option{Flag: "n"}, want: "APP_WRITE_OUT_N"},
+		{set: cmd[1], o: Option{Flag: "dry-run"}, want: "APP_WRITE_OUT_DRY_RUN"},
+		{set: cmd[1], o: Option{Flag: "n", Env: "MY_N"}, want: "MY_N"},
+	}
+	for _, test := range tests {
+		for i := range config.commands {
+			if config.commands[i].flag == test.set {
+				config.set = &config.commands[i]
+			}
+		}
+		if got := envName(config, &test.o); got != test.want {
+			t.Errorf("%s: received %q expected %q",
+				fname, got, test.want)
+		}
+	}
+}
+
+func TestEnvPrecedence(t *testing.T) {
+	const fname = "TestEnvPrecedence"
+	t.Setenv("APP_ONE_N", "24")
+	t.Setenv("APP_ONE_D", "3s")
+	t.Setenv("CUSTOM_S", "env")
+	var s string
+	tests := []struct {
+		args []string
+		n    int
+		d    time.Duration
+		s    string
+	}{
+		{args: []string{"one"}, n: 24, d: 3 * time.Second, s: "env"},
+		{args: []string{"one", "-n", "48", "-s", "arg"},
+			n: 48, d: 3 * time.Second, s: "arg"},
+	}
+	for _, test := range tests {
+		config, cmd := testConfig("one")
+		config.EnvPrefix("APP")
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 12, Commands: Cmds(cmd[0], cmd[1])},
+			{Type: Duration, Flag: "d", Default: time.Second,
+				Commands: cmd[1]},
+			{Type: StringVar, Flag: "s", Default: "default", Var: &s,
+				Env: "CUSTOM_S", Commands: cmd[1]},
+		}
+		if _, err := config.ComposeArgs(test.args, opts...); err != nil {
+			t.Errorf("%s: %s", fname, err)
+			continue
+		}
+		n, err := config.ValueInt("n")
+		if err != nil || n != test.n {
+			t.Errorf("%s: received %d expected %d: %v",
+				fname, n, test.n, err)
+		}
+		d, err := config.ValueDuration("d")
+		if err != nil || d != test.d {
+			t.Errorf("%s: received %s expected %s: %v",
+				fname, d, test.d, err)
+		}
+		if s != test.s {
+			t.Errorf("%s: received %q expected %q", fname, s, test.s)
+		}
+	}
+}
+
+func TestEnvTypeError(t *testing.T) {
+	const fname = "TestEnvTypeError"
+	t.Setenv("APP_N", "twelve")
+	config, cmd := testConfig()
+	config.EnvPrefix("APP")
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 12, Commands: cmd[0]},
+	}
+	_, err := config.ComposeArgs(nil, opts...)
+	if !errors.Is(err, errConfig) {
+		t.Errorf("%s: %s", fname, err)
+	}
+	_, err = config.ValueInt("n")
+	if !errors.Is(err, errType) {
+		t.Errorf("%s: %s", fname, err)
+	}
+}
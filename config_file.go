@@ -0,0 +1,658 @@
+package conf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Files declares the configuration files from which option values are
+// read, they are read in the order given, values in later files taking
+// precedence over those in earlier ones, such that a checked in team
+// configuration may be followed by a per user override. Files that do
+// not exist are skipped. The format of each file is decided by its
+// extension, one of .json .toml .yaml .yml or .ini
+//
+// Keys at the top level of a file are mapped onto the option with the
+// same flag in the running command, keys within a section or table that
+// is named after a command are only used when that command is running,
+// taking precedence over the top level keys. The sections of nested
+// commands are named by their path, joined by dots, [remote.add].
+//
+//	# app.toml
+//	n = 24
+//
+//	[greet]
+//	s = "Hello, World!"
+//
+// File values override the option defaults and are themselves
+// overridden by the environment and the command line.
+func (c *Config) Files(paths ...string) {
+	c.files = append(c.files, paths...)
+}
+
+// valueKind is the kind of data found in a configuration file.
+type valueKind int
+
+const (
+	// kindText is untyped text, as found in ini files.
+	kindText valueKind = iota
+	kindString
+	kindNumber
+	kindBool
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindText:
+		return "text"
+	case kindString:
+		return "string"
+	case kindNumber:
+		return "number"
+	case kindBool:
+		return "bool"
+	default:
+		return "error: unknown kind"
+	}
+}
+
+// fileValue is a value read from a configuration file.
+type fileValue struct {
+	// section is the name of the command that the value is
+	// assigned to, empty at the top level of the file.
+	section string
+	key     string
+	value   string
+	kind    valueKind
+	// list contains the elements of an array, nil for scalar values,
+	// all of the elements are of the same kind.
+	list []string
+	// line is the line of the file on which the key was found.
+	line int
+}
+
+// loadFiles reads every configuration file in order, setting the values
+// that they contain for the current running command set; It is run once
+// the flagset has been created and before it is parsed.
+func loadFiles(c *Config) error {
+	const fname = "loadFiles"
+	for _, path := range c.files {
+		values, err := readFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", fname, err, errConfig)
+		}
+		if err := fileToFlagSet(c, path, values); err != nil {
+			return fmt.Errorf("%s: %w", fname, err)
+		}
+		if v2() && values != nil {
+			log.Printf("%s: %s: loaded\n", fname, path)
+		}
+	}
+
+	if v2() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return nil
+}
+
+// fileToFlagSet sets the values read from a file, top level keys first
+// and then those of the running commands section. Keys and sections that
+// are not known to the Config are reported as errors.
+func fileToFlagSet(c *Config, path string, values []fileValue) error {
+	const fname = "fileToFlagSet"
+	for pass := 0; pass < 2; pass++ {
+		for _, v := range values {
+			if (pass == 0) != (v.section == "") {
+				continue
+			}
+			o, err := fileOption(c, v)
+			if err != nil {
+				err = fmt.Errorf("%s: %s:%d: %w", fname, path, v.line, err)
+				if c.errs != nil {
+					c.errs = fmt.Errorf("%s: %w", c.errs, err)
+				} else {
+					c.errs = err
+				}
+				continue
+			}
+			if o == nil {
+				continue
+			}
+			if err := checkFileValue(o, v); err != nil {
+				storeErr(c, o, fmt.Errorf("%s: %s:%d: %s: %w",
+					fname, path, v.line, v.key, err))
+				continue
+			}
+			if v.list != nil {
+				err = setList(c, o, v.list)
+			} else {
+				err = setFlag(c, o, v.value)
+			}
+			if err != nil {
+				storeErr(c, o, fmt.Errorf("%s: %s:%d: %s: %w",
+					fname, path, v.line, v.key, err))
+				continue
+			}
+			o.src = Source{Kind: FromFile, Path: path, Line: v.line}
+		}
+	}
+
+	if c.errs != nil {
+		return fmt.Errorf("%s: %s: %w", fname, c.errs, errConfig)
+	}
+
+	return nil
+}
+
+// fileOption returns the option that a file value refers to, nil if
+// the option is not in the running command set; An error is returned if
+// the key or section is not known.
+func fileOption(c *Config, v fileValue) (*Option, error) {
+	const fname = "fileOption"
+	if v.section == "" {
+		if !c.Is(v.key) {
+			return nil, fmt.Errorf("%s: %q: %w", fname, v.key, errNoFlag)
+		}
+		return c.set.options.find(v.key), nil
+	}
+	for i := range c.commands[1:] {
+		m := &c.commands[i+1]
+		if cmdPath(c, m, ".") != v.section {
+			continue
+		}
+		if m.options.find(v.key) == nil {
+			return nil, fmt.Errorf("%s: %s.%s: %w",
+				fname, v.section, v.key, errNoFlag)
+		}
+		if m != c.set {
+			return nil, nil
+		}
+		return m.options.find(v.key), nil
+	}
+	return nil, fmt.Errorf("%s: [%s]: %w", fname, v.section, errSubCmd)
+}
+
+// checkFileValue checks that the kind of value read from a file is that
+// required by the options type.
+func checkFileValue(o *Option, v fileValue) error {
+	const fname = "checkFileValue"
+	if v.list != nil && !isSlice(o.Type) && !isMap(o.Type) {
+		return fmt.Errorf("%s: %s: array: %w", fname, o.Type, errType)
+	}
+	ok := v.kind == kindText
+	switch o.Type {
+	case Int, IntVar, Int64, Int64Var, Uint, UintVar,
+		Uint64, Uint64Var, Float64, Float64Var:
+		ok = ok || v.kind == kindNumber
+	case String, StringVar, Duration, DurationVar,
+		StringSlice, StringSliceVar, DurationSlice, DurationSliceVar:
+		ok = ok || v.kind == kindString
+	case StringMap, StringMapVar, IntMap, IntMapVar,
+		Float64Map, Float64MapVar, DurationMap, DurationMapVar:
+		// Maps are given as key=value strings.
+		ok = ok || v.kind == kindString
+	case IntSlice, IntSliceVar, Float64Slice, Float64SliceVar:
+		// A scalar string may hold separated values.
+		ok = ok || v.kind == kindNumber ||
+			v.list == nil && v.kind == kindString
+	case Bool, BoolVar:
+		ok = ok || v.kind == kindBool
+	case Var:
+		ok = true
+	}
+	if !ok {
+		return fmt.Errorf("%s: %s: %s: %w", fname, o.Type, v.kind, errType)
+	}
+	return nil
+}
+
+// readFile reads and parses a configuration file according to its
+// extension, a file that does not exist returns no values.
+func readFile(path string) ([]fileValue, error) {
+	const fname = "readFile"
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	var values []fileValue
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		values, err = parseJSON(data)
+	case ".toml":
+		values, err = parseTOML(data)
+	case ".yaml", ".yml":
+		values, err = parseYAML(data)
+	case ".ini":
+		values, err = parseINI(data)
+	default:
+		err = fmt.Errorf("%q: unknown file format: %w", ext, errNotValid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", fname, path, err)
+	}
+	return values, nil
+}
+
+// parseJSON parses a json object, the values of which are either
+// scalars or objects containing the scalar values of a section.
+func parseJSON(data []byte) ([]fileValue, error) {
+	const fname = "parseJSON"
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	line := func() int {
+		return 1 + bytes.Count(data[:dec.InputOffset()], []byte{'\n'})
+	}
+	fail := func(err error) ([]fileValue, error) {
+		return nil, fmt.Errorf("%s: line %d: %w", fname, line(), err)
+	}
+	delim := func(want json.Delim) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); !ok || d != want {
+			return fmt.Errorf("expected %q: %w", want, errNotValid)
+		}
+		return nil
+	}
+
+	// members reads the members of an object up to and including
+	// its closing delimiter, objects within the top level object are
+	// read as sections.
+	var values []fileValue
+	var members func(section string) error
+	var elements func(v *fileValue) error
+	members = func(section string) error {
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			v := fileValue{section: section, key: tok.(string), line: line()}
+			tok, err = dec.Token()
+			if err != nil {
+				return err
+			}
+			if t, ok := tok.(json.Delim); ok && t == '[' {
+				if err := elements(&v); err != nil {
+					return fmt.Errorf("%s: %w", v.key, err)
+				}
+				values = append(values, v)
+				continue
+			}
+			if t, ok := tok.(json.Delim); ok {
+				if t != '{' || section != "" {
+					return fmt.Errorf("%s: %w", v.key, errNotValid)
+				}
+				if err := members(v.key); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := jsonScalar(tok, &v); err != nil {
+				return fmt.Errorf("%s: %w", v.key, err)
+			}
+			values = append(values, v)
+		}
+		return delim('}')
+	}
+
+	// elements reads the scalar elements of an array up to and
+	// including its closing delimiter.
+	elements = func(v *fileValue) error {
+		v.list, v.kind = []string{}, kindText
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			var e fileValue
+			if err := jsonScalar(tok, &e); err != nil {
+				return err
+			}
+			if err := appendElement(v, e.value, e.kind); err != nil {
+				return err
+			}
+		}
+		return delim(']')
+	}
+	if err := delim('{'); err != nil {
+		return fail(err)
+	}
+	if err := members(""); err != nil {
+		return fail(err)
+	}
+	return values, nil
+}
+
+// jsonScalar sets the value and kind of v from a scalar json token.
+func jsonScalar(tok json.Token, v *fileValue) error {
+	switch t := tok.(type) {
+	case string:
+		v.value, v.kind = t, kindString
+	case json.Number:
+		v.value, v.kind = t.String(), kindNumber
+	case bool:
+		v.value, v.kind = strconv.FormatBool(t), kindBool
+	case json.Delim:
+		return fmt.Errorf("%q: %w", t, errNotValid)
+	default:
+		return errNoValue
+	}
+	return nil
+}
+
+// appendElement appends an element to the array v, returning an error
+// if its kind differs from that of the elements already in the array.
+func appendElement(v *fileValue, value string, kind valueKind) error {
+	if len(v.list) > 0 && kind != v.kind {
+		return fmt.Errorf("mixed array: %s: %s: %w", v.kind, kind, errNotValid)
+	}
+	v.list, v.kind = append(v.list, value), kind
+	return nil
+}
+
+// array parses a flow array, [a, b, c], into the list of v; Unquoted
+// elements that are neither numbers nor booleans take the kind def.
+func array(s string, def valueKind, v *fileValue) error {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[len(s)-1] != ']' {
+		return fmt.Errorf("%s: %w", s, errNotValid)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+	v.list, v.kind = []string{}, kindText
+	for s != "" {
+		i := elementEnd(s)
+		value, kind, err := scalar(s[:i], def)
+		if err != nil {
+			return err
+		}
+		if err := appendElement(v, value, kind); err != nil {
+			return err
+		}
+		if i == len(s) {
+			break
+		}
+		s = strings.TrimSpace(s[i+1:])
+	}
+	return nil
+}
+
+// elementEnd returns the index of the first comma in s that is not
+// quoted, the length of s if there is none.
+func elementEnd(s string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case quote != 0:
+			if b == '\\' && quote == '"' {
+				i++
+			} else if b == quote {
+				quote = 0
+			}
+		case b == '"' || b == '\'':
+			quote = b
+		case b == ',':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// lines calls fn for every line of data that is neither empty nor a
+// comment, with its line number; Comments start with any of the given
+// marks and run to the end of the line, marks within quotes are not
+// considered.
+func lines(data []byte, marks string, fn func(n int, line string) error) error {
+	scan := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scan.Scan(); n++ {
+		line := stripComment(scan.Text(), marks)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := fn(n, line); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return scan.Err()
+}
+
+// stripComment removes a trailing comment from the line.
+func stripComment(line, marks string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch b := line[i]; {
+		case quote != 0:
+			if b == '\\' && quote == '"' {
+				i++
+			} else if b == quote {
+				quote = 0
+			}
+		case b == '"' || b == '\'':
+			quote = b
+		case strings.IndexByte(marks, b) >= 0:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// scalar classifies and unquotes a value, unquoted values that are
+// neither numbers nor booleans are returned with the kind def.
+func scalar(s string, def valueKind) (string, valueKind, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return "", def, errNoValue
+	case s[0] == '"':
+		u, err := strconv.Unquote(s)
+		if err != nil {
+			return "", def, fmt.Errorf("%s: %w", s, errNotValid)
+		}
+		return u, kindString, nil
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return "", def, fmt.Errorf("%s: %w", s, errNotValid)
+		}
+		return s[1 : len(s)-1], kindString, nil
+	case s == "true" || s == "false":
+		return s, kindBool, nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64); err == nil {
+		return strings.ReplaceAll(s, "_", ""), kindNumber, nil
+	}
+	return s, def, nil
+}
+
+// section returns the name of a section header, [name], and true if the
+// line is a header.
+func section(line string) (string, bool, error) {
+	line = strings.TrimSpace(line)
+	if line[0] != '[' {
+		return "", false, nil
+	}
+	if line[len(line)-1] != ']' {
+		return "", true, fmt.Errorf("%s: %w", line, errNotValid)
+	}
+	return strings.TrimSpace(line[1 : len(line)-1]), true, nil
+}
+
+// parseTOML parses the subset of toml that maps onto options, key value
+// pairs of strings numbers booleans and inline arrays of them, within
+// optional tables.
+func parseTOML(data []byte) ([]fileValue, error) {
+	const fname = "parseTOML"
+	var values []fileValue
+	var table string
+	err := lines(data, "#", func(n int, line string) error {
+		name, ok, err := section(line)
+		if err != nil {
+			return err
+		}
+		if ok {
+			table = name
+			return nil
+		}
+		i := strings.IndexByte(line, '=')
+		if i < 1 {
+			return fmt.Errorf("%s: %w", line, errNotValid)
+		}
+		v := fileValue{section: table, line: n}
+		v.key = strings.TrimSpace(line[:i])
+		if rest := strings.TrimSpace(line[i+1:]); strings.HasPrefix(rest, "[") {
+			err = array(rest, kindText, &v)
+		} else {
+			v.value, v.kind, err = scalar(rest, kindText)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.key, err)
+		}
+		// Only an empty array is without a kind.
+		if v.kind == kindText && (v.list == nil || len(v.list) > 0) {
+			return fmt.Errorf("%s: unquoted string: %w", v.key, errNotValid)
+		}
+		values = append(values, v)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	return values, nil
+}
+
+// parseYAML parses the subset of yaml that maps onto options, a mapping
+// of scalar values or of mappings of scalar values, one level deep;
+// Values may also be flow sequences, [a, b], or block sequences of
+// scalars, one element to a line, each starting with a dash.
+func parseYAML(data []byte) ([]fileValue, error) {
+	const fname = "parseYAML"
+	var values []fileValue
+	var mapping string
+	// list is the index of the value that a block sequence is being
+	// read into, -1 when there is none.
+	list := -1
+	// open is set when a key has been given without a value, its
+	// value being either a mapping or a block sequence.
+	var open *fileValue
+	// noValue reports a key within a mapping that has neither a value
+	// nor a block sequence.
+	noValue := func() error {
+		if open != nil && open.section != "" {
+			return fmt.Errorf("%s: %w", open.key, errNoValue)
+		}
+		return nil
+	}
+	err := lines(data, "#", func(n int, line string) error {
+		if strings.TrimSpace(line) == "---" {
+			return nil
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		if item := strings.TrimSpace(line); indented && strings.HasPrefix(item, "-") {
+			if open != nil {
+				if open.section == "" {
+					mapping = ""
+				}
+				open.list, open.kind = []string{}, kindText
+				values = append(values, *open)
+				list, open = len(values)-1, nil
+			}
+			if list < 0 {
+				return fmt.Errorf("%s: unexpected sequence: %w",
+					item, errNotValid)
+			}
+			value, kind, err := scalar(item[1:], kindString)
+			if err != nil {
+				return fmt.Errorf("%s: %w", values[list].key, err)
+			}
+			return appendElement(&values[list], value, kind)
+		}
+		if err := noValue(); err != nil {
+			return err
+		}
+		list, open = -1, nil
+		i := strings.IndexByte(line, ':')
+		if i < 1 {
+			return fmt.Errorf("%s: %w", line, errNotValid)
+		}
+		key := strings.TrimSpace(line[:i])
+		rest := strings.TrimSpace(line[i+1:])
+		if !indented {
+			mapping = ""
+		} else if mapping == "" {
+			return fmt.Errorf("%s: unexpected indentation: %w",
+				key, errNotValid)
+		}
+		v := fileValue{section: mapping, key: key, line: n}
+		if rest == "" {
+			if !indented {
+				mapping = key
+			}
+			open = &v
+			return nil
+		}
+		var err error
+		if strings.HasPrefix(rest, "[") {
+			err = array(rest, kindString, &v)
+		} else {
+			v.value, v.kind, err = scalar(rest, kindString)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		values = append(values, v)
+		return nil
+	})
+	if err == nil {
+		err = noValue()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	return values, nil
+}
+
+// parseINI parses an ini file, all values are untyped text, quoted
+// values have their quotes removed.
+func parseINI(data []byte) ([]fileValue, error) {
+	const fname = "parseINI"
+	var values []fileValue
+	var sect string
+	err := lines(data, ";#", func(n int, line string) error {
+		name, ok, err := section(line)
+		if err != nil {
+			return err
+		}
+		if ok {
+			sect = name
+			return nil
+		}
+		i := strings.IndexAny(line, "=:")
+		if i < 1 {
+			return fmt.Errorf("%s: %w", line, errNotValid)
+		}
+		v := fileValue{section: sect, line: n, kind: kindText}
+		v.key = strings.TrimSpace(line[:i])
+		v.value = strings.TrimSpace(line[i+1:])
+		if l := len(v.value); l > 1 && (v.value[0] == '"' || v.value[0] == '\'') &&
+			v.value[l-1] == v.value[0] {
+			v.value = v.value[1 : l-1]
+		}
+		values = append(values, v)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,159 @@
+package conf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFileFormats(t *testing.T) {
+	const fname = "TestFileFormats"
+	files := map[string]string{
+		"app.json": `{
+	"n": 24,
+	"one": {"n": 36, "s": "file", "d": "3s", "b": true}
+}`,
+		"app.toml": `# team settings
+n = 24
+
+[one]
+n = 36
+s = "file" # the s flag
+d = '3s'
+b = true
+`,
+		"app.yaml": `---
+n: 24
+one:
+  n: 36
+  s: file
+  d: "3s"
+  b: true
+`,
+		"app.ini": `; team settings
+n = 24
+
+[one]
+n = 36
+s = "file"
+d: 3s
+b = true
+`,
+	}
+	compose := func(path string, args ...string) (*Config, error) {
+		config, cmd := testConfig("one")
+		config.Files(path)
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 12, Commands: Cmds(cmd[0], cmd[1])},
+			{Type: String, Flag: "s", Default: "default", Commands: cmd[1]},
+			{Type: Duration, Flag: "d", Default: time.Second, Commands: cmd[1]},
+			{Type: Bool, Flag: "b", Default: false, Commands: cmd[1]},
+		}
+		_, err := config.ComposeArgs(args, opts...)
+		return config, err
+	}
+	for name, data := range files {
+		path := writeFile(t, name, data)
+		config, err := compose(path, "one")
+		if err != nil {
+			t.Errorf("%s: %s: %s", fname, name, err)
+			continue
+		}
+		if n, _ := config.ValueInt("n"); n != 36 {
+			t.Errorf("%s: %s: received %d expected 36", fname, name, n)
+		}
+		if s, _ := config.ValueString("s"); s != "file" {
+			t.Errorf("%s: %s: received %q expected \"file\"", fname, name, s)
+		}
+		if d, _ := config.ValueDuration("d"); d != 3*time.Second {
+			t.Errorf("%s: %s: received %s expected 3s", fname, name, d)
+		}
+		if b, _ := config.ValueBool("b"); !b {
+			t.Errorf("%s: %s: received false expected true", fname, name)
+		}
+
+		// The top level applies to the default set.
+		if config, err = compose(path); err != nil {
+			t.Errorf("%s: %s: %s", fname, name, err)
+			continue
+		}
+		if n, _ := config.ValueInt("n"); n != 24 {
+			t.Errorf("%s: %s: received %d expected 24", fname, name, n)
+		}
+	}
+}
+
+func TestFileLayers(t *testing.T) {
+	const fname = "TestFileLayers"
+	team := writeFile(t, "team.toml", "n = 1\n[one]\ns = \"team\"\n")
+	user := writeFile(t, "user.ini", "[one]\ns = user\n")
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	config, cmd := testConfig("one")
+	config.Files(team, user, missing)
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 12, Commands: Cmds(cmd[0], cmd[1])},
+		{Type: String, Flag: "s", Default: "default", Commands: cmd[1]},
+		{Type: Duration, Flag: "d", Default: time.Second, Commands: cmd[1]},
+		{Type: Bool, Flag: "b", Default: false, Commands: cmd[1]},
+	}
+	if _, err := config.ComposeArgs([]string{"one", "-n", "2"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if n, _ := config.ValueInt("n"); n != 2 {
+		t.Errorf("%s: received %d expected 2", fname, n)
+	}
+	if s, _ := config.ValueString("s"); s != "user" {
+		t.Errorf("%s: received %q expected \"user\"", fname, s)
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	const fname = "TestFileErrors"
+	tests := []struct {
+		name string
+		data string
+		err  error
+		line string
+	}{
+		{name: "type.json", data: "{\n\"n\": \"twelve\"\n}", err: errType, line: ":2:"},
+		{name: "type.toml", data: "[one]\ns = 12\n", err: errType, line: ":2:"},
+		{name: "range.yaml", data: "n: 1.5\n", err: errType, line: ":1:"},
+		{name: "flag.toml", data: "x = 1\n", err: errNoFlag, line: ":1:"},
+		{name: "section.ini", data: "[two]\nn = 1\n", err: errSubCmd, line: ":2:"},
+		{name: "syntax.toml", data: "s = file\n", err: errNotValid},
+		{name: "format.xml", data: "<n>1</n>", err: errNotValid},
+	}
+	for _, test := range tests {
+		path := writeFile(t, test.name, test.data)
+		config, cmd := testConfig("one")
+		config.Files(path)
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 12, Commands: Cmds(cmd[0], cmd[1])},
+			{Type: String, Flag: "s", Default: "default", Commands: cmd[1]},
+			{Type: Duration, Flag: "d", Default: time.Second, Commands: cmd[1]},
+			{Type: Bool, Flag: "b", Default: false, Commands: cmd[1]},
+		}
+		_, err := config.ComposeArgs([]string{"one"}, opts...)
+		if !errors.Is(err, errConfig) {
+			t.Errorf("%s: %s: %s", fname, test.name, err)
+			continue
+		}
+		if !errors.Is(config.errs, test.err) && !strings.Contains(err.Error(), test.err.Error()) {
+			t.Errorf("%s: %s: %s", fname, test.name, err)
+		}
+		if !strings.Contains(err.Error(), path+test.line) {
+			t.Errorf("%s: %s: no line number: %s", fname, test.name, err)
+		}
+	}
+}
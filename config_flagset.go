@@ -1,19 +1,86 @@
 package conf
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
+	"strconv"
 	"time"
 )
 
+// ErrorHandling defines how Compose behaves when the command line
+// arguments can not be parsed.
+type ErrorHandling int
+
+const (
+	// ExitOnError exits the program with the status ExitUsage, or
+	// ExitOK when help was requested, the default.
+	ExitOnError ErrorHandling = iota
+	// ContinueOnError returns the error from Compose, a ParseError
+	// or ErrHelp.
+	ContinueOnError
+	// PanicOnError panics with the error.
+	PanicOnError
+)
+
+// SetErrorHandling sets the behaviour of Compose when the command line
+// arguments can not be parsed, or help is requested; In every case the
+// error and the usage are first written to the Config's output.
+//
+//	c.SetErrorHandling(conf.ContinueOnError)
+func (c *Config) SetErrorHandling(h ErrorHandling) {
+	c.handling = h
+}
+
+// ParseError is the error returned by Compose when the command line
+// arguments can not be parsed and the Config continues on error.
+type ParseError struct {
+	// Command is the path of the running command, empty for the
+	// default set.
+	Command string
+	// Err is the error raised by the flagset.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.Command == "" {
+		return e.Err.Error()
+	}
+	return e.Command + ": " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseError applies the Config's error handling to an error raised by
+// parsing the flagset, returning either ErrHelp or a ParseError when it
+// continues on error.
+func parseError(c *Config, err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		err = ErrHelp
+	} else {
+		err = &ParseError{Command: cmdPath(c, c.set, " "), Err: err}
+	}
+	switch c.handling {
+	case ExitOnError:
+		if err == ErrHelp {
+			os.Exit(ExitOK)
+		}
+		os.Exit(ExitUsage)
+	case PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
 // createFlagSet defines the flagset for all options that have
 // been specified within the current working set; All errors are
 // accumulated in the Config.errs field and checked at the end of the
 // function.
-func setupFlagSet(c *Config) error {
+func setupFlagSet(c *Config, args []string) error {
 	const fname = "createFlagSet"
 
 	if c.set == nil {
@@ -21,13 +88,25 @@ func setupFlagSet(c *Config) error {
 		return fmt.Errorf("%s: %s", fname, event)
 	}
 
-	createFlagSet(c, os.Stdout)
+	createFlagSet(c)
 
 	if err := optionsToFlagSet(c); err != nil {
 		return fmt.Errorf("%s: %w", fname, err)
 	}
 
-	if err := parseFlagSet(c); err != nil {
+	if err := loadFiles(c); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+
+	if err := loadStore(c); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+
+	if err := loadEnv(c); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+
+	if err := parseFlagSet(c, args); err != nil {
 		return fmt.Errorf("%s: %w", fname, err)
 	}
 
@@ -38,27 +117,24 @@ func setupFlagSet(c *Config) error {
 	return nil
 }
 
-func createFlagSet(c *Config, w io.Writer) {
+func createFlagSet(c *Config) {
 	const fname = "createFlagSet"
 
 	if c.set == nil {
 		panic(fname + ": c.set is nil")
 	}
 
-	// Create our custom flagset.
-	c.flagSet = flag.NewFlagSet(c.set.cmd, flag.ExitOnError)
+	// Create our custom flagset, named by the commands path.
+	name := c.set.cmd
+	if c.set.parent >= 0 {
+		name = cmdPath(c, c.set, " ")
+	}
+	// Errors are handled by parseError.
+	c.flagSet = flag.NewFlagSet(name, flag.ContinueOnError)
 
 	// Define help or usage output function, overriding the default
 	// flag package help function.
-	if w == nil {
-		w = os.Stderr
-	}
-	c.flagSet.SetOutput(w)
-	c.flagSet.Usage = func() {
-		io.WriteString(w, c.header)
-		io.WriteString(w, c.set.usage)
-		c.flagSet.VisitAll(flagUsage)
-	}
+	setUsageFn(nil, c)
 
 	if v2() {
 		log.Printf("%s: completed\n", fname)
@@ -68,14 +144,21 @@ func createFlagSet(c *Config, w io.Writer) {
 func optionsToFlagSet(c *Config) error {
 	const fname = "optionsToFlagSet"
 	for _, o := range c.set.options {
-		if c.set.flag&o.Commands != 0 {
+		if c.set.flag.Has(o.Commands) {
 			opt := c.set.options.find(o.Flag)
 			err := flagsToFlagSet(c, opt)
+			if err == nil && len(opt.Choices) > 0 {
+				err = choiceFlag(c, opt)
+			}
+			if err == nil && hasConstraints(opt) {
+				err = constraintFlag(c, opt)
+			}
+			if err == nil && opt.Required.Has(c.set.flag) {
+				err = requiredFlag(c, opt)
+			}
 			if err != nil {
-				opt.err = fmt.Errorf("%s: %s: %w",
-					fname, o.Flag, err)
-				c.errs = fmt.Errorf("%s: %s: %w",
-					fname, c.errs.Error(), opt.err)
+				storeErr(c, opt, fmt.Errorf("%s: %s: %w",
+					fname, o.Flag, err))
 			}
 			if v3() {
 				log.Printf("%s: %s: option added\n",
@@ -260,6 +343,30 @@ func flagsToFlagSet(c *Config, o *Option) error {
 				errTypeNil)
 		}
 		c.flagSet.Var(o.Value, o.Flag, o.Usage)
+	case StringSlice, StringSliceVar:
+		return flagSlice(c, o, o.Type == StringSliceVar,
+			parseString, formatString)
+	case IntSlice, IntSliceVar:
+		return flagSlice(c, o, o.Type == IntSliceVar,
+			strconv.Atoi, strconv.Itoa)
+	case Float64Slice, Float64SliceVar:
+		return flagSlice(c, o, o.Type == Float64SliceVar,
+			parseFloat64, formatFloat64)
+	case DurationSlice, DurationSliceVar:
+		return flagSlice(c, o, o.Type == DurationSliceVar,
+			time.ParseDuration, time.Duration.String)
+	case StringMap, StringMapVar:
+		return flagMap(c, o, o.Type == StringMapVar,
+			parseString, formatString)
+	case IntMap, IntMapVar:
+		return flagMap(c, o, o.Type == IntMapVar,
+			strconv.Atoi, strconv.Itoa)
+	case Float64Map, Float64MapVar:
+		return flagMap(c, o, o.Type == Float64MapVar,
+			parseFloat64, formatFloat64)
+	case DurationMap, DurationMapVar:
+		return flagMap(c, o, o.Type == DurationMapVar,
+			time.ParseDuration, time.Duration.String)
 	case Nil:
 		return fmt.Errorf("%s: %q: %w", o.Type, def,
 			errTypeNil)
@@ -273,24 +380,89 @@ func flagsToFlagSet(c *Config, o *Option) error {
 	return nil
 }
 
-// parseFlagSet runs the parse command on the configs flagset.
-func parseFlagSet(c *Config) error {
-	const fname = "parseFlagSet"
+// setFlag sets the value of an option's flag from its string form,
+// using the type conversion of the flag that was created for it. The
+// value is set directly, not through the flagset, such that it acts as
+// the flags default and only those flags given on the command line are
+// recorded by the flagset as having been set. The value replaces any
+// that a previous source has set.
+func setFlag(c *Config, o *Option, value string) error {
+	const fname = "setFlag"
+	f := c.flagSet.Lookup(o.Flag)
+	if f == nil {
+		return fmt.Errorf("%s: %s: %w", fname, o.Flag, errNoFlag)
+	}
+	if r, ok := f.Value.(resetter); ok {
+		r.reset()
+	}
+	if err := f.Value.Set(value); err != nil {
+		return fmt.Errorf("%s: %s: %q: %s: %w",
+			fname, o.Type, value, err, errType)
+	}
+	return nil
+}
 
-	// When tests are being run, we do not parse the flagset here.
-	if test {
-		return nil
+// setList sets the value of a list option's flag from its elements, in
+// the same manner as setFlag.
+func setList(c *Config, o *Option, elements []string) error {
+	const fname = "setList"
+	f := c.flagSet.Lookup(o.Flag)
+	if f == nil {
+		return fmt.Errorf("%s: %s: %w", fname, o.Flag, errNoFlag)
 	}
-	offset := 1 // the program call needs to be skipped.
-	// If not the default then a command has been used and we need
-	// to offset the args by one more place.
-	if c.set.flag != 1 {
-		offset++
+	l, ok := f.Value.(lister)
+	if !ok {
+		return fmt.Errorf("%s: %s: not a list: %w", fname, o.Type, errType)
+	}
+	if err := l.setList(elements); err != nil {
+		return fmt.Errorf("%s: %s: %q: %s: %w",
+			fname, o.Type, elements, err, errType)
 	}
-	err := c.flagSet.Parse(os.Args[offset:])
+	return nil
+}
+
+// storeErr records an error that has been raised by an option, both
+// within the option itself and in the Config's accumulated errors.
+func storeErr(c *Config, o *Option, err error) {
+	o.err = err
+	if c.errs != nil {
+		c.errs = fmt.Errorf("%s: %w", c.errs, err)
+	} else {
+		c.errs = err
+	}
+}
+
+// parseFlagSet runs the parse command on the configs flagset, using the
+// given arguments.
+func parseFlagSet(c *Config, args []string) error {
+	const fname = "parseFlagSet"
+
+	// If not the default then a command has been used and we need
+	// to offset the args by the depth of the command.
+	offset := c.set.depth
+	// Values set on the command line replace those of all other
+	// sources.
+	c.flagSet.VisitAll(func(f *flag.Flag) {
+		if r, ok := f.Value.(resetter); ok {
+			r.reset()
+		}
+	})
+	// The usage is written once parsing is done, to the standard
+	// output when requested and else to the error output, following
+	// any error message from the flagset.
+	usage := c.flagSet.Usage
+	c.flagSet.Usage = func() {}
+	err := c.flagSet.Parse(args[offset:])
+	c.flagSet.Usage = usage
 	if err != nil {
-		return fmt.Errorf("%s: %w", fname, err)
+		w := stderr(c)
+		if errors.Is(err, flag.ErrHelp) {
+			w = stdout(c)
+		}
+		writeUsage(c, w)
+		return fmt.Errorf("%s: %w", fname, parseError(c, err))
 	}
+	argsSource(c, args, offset)
 
 	if v2() {
 		log.Printf("%s: completed\n", fname)
@@ -0,0 +1,129 @@
+package conf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorHandling(t *testing.T) {
+	const fname = "TestErrorHandling"
+	tests := []struct {
+		args []string
+		cmd  string
+		err  error
+	}{
+		{args: []string{"-n", "2"}},
+		{args: []string{"-h"}, err: ErrHelp},
+		{args: []string{"one", "-help"}, err: ErrHelp},
+		{args: []string{"-x"}, err: &ParseError{}},
+		{args: []string{"one", "-n", "x"}, cmd: "one", err: &ParseError{}},
+		{args: []string{"-l", "1,x"}, err: &ParseError{}},
+		{args: []string{"-f", "xml"}, err: &ParseError{}},
+	}
+	for _, test := range tests {
+		config, cmd := testConfig("one")
+		config.SetErrorHandling(ContinueOnError)
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 1, Commands: Cmds(cmd[0], cmd[1])},
+			{Type: IntSlice, Flag: "l", Default: []int{}, Commands: cmd[0]},
+			{Type: String, Flag: "f", Default: "text", Commands: cmd[0],
+				Choices: []string{"text", "json"}},
+		}
+		_, err := config.ComposeArgs(test.args, opts...)
+		var pe *ParseError
+		switch {
+		case test.err == nil && err != nil:
+			t.Errorf("%s: %v: %s", fname, test.args, err)
+		case test.err == ErrHelp && !errors.Is(err, ErrHelp):
+			t.Errorf("%s: %v: received %v expected %v",
+				fname, test.args, err, ErrHelp)
+		case test.err != nil && test.err != ErrHelp:
+			if !errors.As(err, &pe) {
+				t.Errorf("%s: %v: received %v expected a ParseError",
+					fname, test.args, err)
+			} else if pe.Command != test.cmd {
+				t.Errorf("%s: %v: received %q expected %q",
+					fname, test.args, pe.Command, test.cmd)
+			}
+		}
+	}
+}
+
+func TestErrorHandlingPanic(t *testing.T) {
+	const fname = "TestErrorHandlingPanic"
+	config, cmd := testConfig()
+	config.SetErrorHandling(PanicOnError)
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 1, Commands: cmd[0]},
+	}
+	defer func() {
+		err, ok := recover().(error)
+		var pe *ParseError
+		if !ok || !errors.As(err, &pe) {
+			t.Errorf("%s: received %v expected a ParseError", fname, err)
+		}
+	}()
+	config.ComposeArgs([]string{"-n", "x"}, opts...)
+}
+
+func TestSetOutput(t *testing.T) {
+	const fname = "TestSetOutput"
+	tests := []struct {
+		args      []string
+		out, errs []string
+	}{
+		{args: []string{"-h"}, out: []string{"Usage heading", "-n"}},
+		{args: []string{"-x"}, errs: []string{
+			"flag provided but not defined: -x", "Usage heading", "-n"}},
+		{args: []string{"-n", "2"}},
+	}
+	for _, test := range tests {
+		var out, errs bytes.Buffer
+		config, cmd := testConfig()
+		config.SetErrorHandling(ContinueOnError)
+		config.SetOutput(&out, &errs)
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 1, Usage: "a number",
+				Commands: cmd[0]},
+		}
+		config.ComposeArgs(test.args, opts...)
+		for _, w := range []struct {
+			name string
+			buf  *bytes.Buffer
+			want []string
+		}{{"stdout", &out, test.out}, {"stderr", &errs, test.errs}} {
+			if w.want == nil && w.buf.Len() > 0 {
+				t.Errorf("%s: %v: %s: unexpected output %q",
+					fname, test.args, w.name, w.buf)
+			}
+			for _, want := range w.want {
+				if !strings.Contains(w.buf.String(), want) {
+					t.Errorf("%s: %v: %s: %q not in %q",
+						fname, test.args, w.name, want, w.buf)
+				}
+			}
+		}
+	}
+}
+
+func TestSetOutputExecute(t *testing.T) {
+	const fname = "TestSetOutputExecute"
+	var out, errs bytes.Buffer
+	config, cmd := testConfig()
+	config.SetOutput(&out, &errs)
+	config.Handle(cmd[0], func(context.Context, *Config, []string) error {
+		return errors.New("failed")
+	})
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 1, Commands: cmd[0]},
+	}
+	if code := config.ExecuteArgs(context.Background(), nil, opts...); code != ExitFailure {
+		t.Errorf("%s: received %d expected %d", fname, code, ExitFailure)
+	}
+	if errs.String() != "failed\n" || out.Len() > 0 {
+		t.Errorf("%s: received %q %q", fname, out.String(), errs.String())
+	}
+}
@@ -0,0 +1,216 @@
+package conf
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Flag groups
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// GroupRule is the relationship between the flags of a group.
+type GroupRule int
+
+const (
+	// GroupExclusive at most one of the flags may be set.
+	GroupExclusive GroupRule = iota
+	// GroupRequires when the first flag is set all of the others
+	// must also be set.
+	GroupRequires
+	// GroupAtLeastOne at least one of the flags must be set.
+	GroupAtLeastOne
+)
+
+func (r GroupRule) String() string {
+	switch r {
+	case GroupExclusive:
+		return "exclusive"
+	case GroupRequires:
+		return "requires"
+	case GroupAtLeastOne:
+		return "at least one"
+	default:
+		return "error: unknown rule"
+	}
+}
+
+// group is a set of flags and the rule that binds them.
+type group struct {
+	rule  GroupRule
+	flags []string
+}
+
+// String returns the description of the group as shown in the usage
+// output.
+func (g group) String() string {
+	flags := make([]string, len(g.flags))
+	for i, f := range g.flags {
+		flags[i] = "-" + f
+	}
+	switch g.rule {
+	case GroupExclusive:
+		return "at most one of " + strings.Join(flags, " ")
+	case GroupRequires:
+		return flags[0] + " requires " + strings.Join(flags[1:], " ")
+	default:
+		return "at least one of " + strings.Join(flags, " ")
+	}
+}
+
+// Exclusive declares that at most one of the given flags may be set.
+//
+//	c.Exclusive("json", "yaml", "text")
+func (c *Config) Exclusive(flags ...string) {
+	addGroup(c, "Config.Exclusive", GroupExclusive, flags)
+}
+
+// Requires declares that when the flag is set, all of the others must
+// also be set.
+//
+//	c.Requires("cert", "key")
+func (c *Config) Requires(flag string, flags ...string) {
+	addGroup(c, "Config.Requires", GroupRequires,
+		append([]string{flag}, flags...))
+}
+
+// AtLeastOne declares that at least one of the given flags must be set.
+//
+//	c.AtLeastOne("id", "name")
+func (c *Config) AtLeastOne(flags ...string) {
+	addGroup(c, "Config.AtLeastOne", GroupAtLeastOne, flags)
+}
+
+// addGroup registers a group, any errors are accumulated into the
+// Config in the same manner as Config.Command.
+func addGroup(c *Config, fname string, rule GroupRule, flags []string) {
+	if len(flags) < 2 {
+		const event = "a group requires at least two flags"
+		c.errs = fmt.Errorf("%s: %s: %w", fname, event, errConfig)
+		return
+	}
+	c.groups = append(c.groups, group{rule: rule, flags: flags})
+}
+
+// GroupError describes the violation of a flag group.
+type GroupError struct {
+	// Rule is the rule of the group.
+	Rule GroupRule
+	// Flags are the flags of the group.
+	Flags []string
+	// Set are those flags of the group that were set.
+	Set []string
+}
+
+func (e *GroupError) Error() string {
+	g := group{rule: e.Rule, flags: e.Flags}
+	if len(e.Set) == 0 {
+		return "flag group: " + g.String()
+	}
+	return fmt.Sprintf("flag group: %s: given -%s",
+		g, strings.Join(e.Set, " -"))
+}
+
+// Unwrap returns ErrGroup, such that every GroupError may be identified
+// by errors.Is.
+func (e *GroupError) Unwrap() error {
+	return ErrGroup
+}
+
+// GroupErrors are all of the group violations of the running command,
+// returned together by Compose.
+type GroupErrors []*GroupError
+
+func (e GroupErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Unwrap returns ErrGroup.
+func (e GroupErrors) Unwrap() error {
+	return ErrGroup
+}
+
+// groups returns the groups that apply to the running command, those of
+// which at least one flag belongs to the command.
+func groups(c *Config) []group {
+	var out []group
+	for _, g := range c.groups {
+		for _, f := range g.flags {
+			if c.set.options.find(f) != nil {
+				out = append(out, g)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// runGroups checks the groups that apply to the running command, a flag
+// being set when its value has come from any source other than its
+// Default; Flags that do not belong to the running command are never
+// set. All violations are returned together as GroupErrors.
+func runGroups(c *Config) error {
+	const fname = "runGroups"
+	for _, g := range c.groups {
+		for _, f := range g.flags {
+			if !c.Is(f) {
+				return fmt.Errorf("%s: %s: -%s: %s: %w",
+					fname, g, f, errNoFlag, errConfig)
+			}
+		}
+	}
+	var errs GroupErrors
+	for _, g := range groups(c) {
+		var set []string
+		for _, f := range g.flags {
+			o := c.set.options.find(f)
+			if o != nil && o.src.Kind != FromDefault {
+				set = append(set, f)
+			}
+		}
+		var ok bool
+		switch g.rule {
+		case GroupExclusive:
+			ok = len(set) <= 1
+		case GroupRequires:
+			ok = len(set) == 0 || set[0] != g.flags[0] ||
+				len(set) == len(g.flags)
+		case GroupAtLeastOne:
+			ok = len(set) > 0
+		}
+		if !ok {
+			errs = append(errs, &GroupError{Rule: g.rule,
+				Flags: g.flags, Set: set})
+		}
+	}
+	if errs != nil {
+		c.errs = errs
+		return fmt.Errorf("%s: %w", fname, errs)
+	}
+
+	if v2() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return nil
+}
+
+// groupsUsage returns the usage section listing the groups that apply
+// to the running command, an empty string if there are none.
+func groupsUsage(c *Config) string {
+	g := groups(c)
+	if len(g) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("FLAG GROUPS\n")
+	for _, g := range g {
+		fmt.Fprintf(&b, "        %s\n", g)
+	}
+	return b.String()
+}
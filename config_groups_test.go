@@ -0,0 +1,122 @@
+package conf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	const fname = "TestGroups"
+	tests := []struct {
+		args  []string
+		rules []GroupRule
+		set   [][]string
+	}{
+		{args: []string{"-id", "1"}},
+		{args: []string{"-name", "x", "-json", "-cert", "c", "-key", "k"}},
+		{args: []string{"-id", "1", "-key", "k"}},
+		{args: []string{"one", "-id", "1"}},
+		{args: []string{"-id", "1", "-json", "-yaml"},
+			rules: []GroupRule{GroupExclusive},
+			set:   [][]string{{"json", "yaml"}}},
+		{args: []string{"-cert", "c", "-json", "-text"},
+			rules: []GroupRule{GroupExclusive, GroupRequires, GroupAtLeastOne},
+			set:   [][]string{{"json", "text"}, {"cert"}, nil}},
+		{args: []string{"one"}, rules: []GroupRule{GroupAtLeastOne},
+			set: [][]string{nil}},
+	}
+	for _, test := range tests {
+		config, cmd := testConfig("one")
+		config.Exclusive("json", "yaml", "text")
+		config.Requires("cert", "key")
+		config.AtLeastOne("id", "name")
+		opts := []Option{
+			{Type: Bool, Flag: "json", Default: false, Commands: cmd[0]},
+			{Type: Bool, Flag: "yaml", Default: false, Commands: cmd[0]},
+			{Type: Bool, Flag: "text", Default: false, Commands: cmd[0]},
+			{Type: String, Flag: "cert", Default: "", Commands: cmd[0]},
+			{Type: String, Flag: "key", Default: "", Commands: cmd[0]},
+			{Type: String, Flag: "id", Default: "",
+				Commands: Cmds(cmd[0], cmd[1])},
+			{Type: String, Flag: "name", Default: "", Commands: cmd[0]},
+		}
+		_, err := config.ComposeArgs(test.args, opts...)
+		if test.rules == nil {
+			if err != nil {
+				t.Errorf("%s: %v: %s", fname, test.args, err)
+			}
+			continue
+		}
+		var errs GroupErrors
+		if !errors.As(err, &errs) || !errors.Is(err, ErrGroup) {
+			t.Errorf("%s: %v: received %v expected %v",
+				fname, test.args, err, ErrGroup)
+			continue
+		}
+		if len(errs) != len(test.rules) {
+			t.Errorf("%s: %v: received %d errors expected %d",
+				fname, test.args, len(errs), len(test.rules))
+			continue
+		}
+		for i, e := range errs {
+			if e.Rule != test.rules[i] || !reflect.DeepEqual(e.Set, test.set[i]) {
+				t.Errorf("%s: %v: received %s %v expected %s %v", fname,
+					test.args, e.Rule, e.Set, test.rules[i], test.set[i])
+			}
+		}
+	}
+}
+
+func TestGroupsInvalid(t *testing.T) {
+	const fname = "TestGroupsInvalid"
+	for _, flags := range [][]string{{"id"}, {"id", "nothere"}} {
+		config, cmd := testConfig()
+		config.Exclusive(flags...)
+		opts := []Option{
+			{Type: String, Flag: "id", Default: "", Commands: cmd[0]},
+		}
+		if _, err := config.ComposeArgs([]string{}, opts...); !errors.Is(err, errConfig) {
+			t.Errorf("%s: %v: received %v expected %v",
+				fname, flags, err, errConfig)
+		}
+	}
+}
+
+func TestGroupsUsage(t *testing.T) {
+	const fname = "TestGroupsUsage"
+	config, cmd := testConfig("one")
+	config.Exclusive("json", "yaml")
+	config.AtLeastOne("id", "name")
+	opts := []Option{
+		{Type: Bool, Flag: "json", Default: false, Commands: cmd[0]},
+		{Type: Bool, Flag: "yaml", Default: false, Commands: cmd[0]},
+		{Type: String, Flag: "id", Default: "", Commands: Cmds(cmd[0], cmd[1])},
+		{Type: String, Flag: "name", Default: "", Commands: cmd[0]},
+	}
+	if _, err := config.ComposeArgs([]string{"one", "-id", "1"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	want := "FLAG GROUPS\n        at least one of -id -name\n"
+	if got := groupsUsage(config); got != want {
+		t.Errorf("%s: received %q expected %q", fname, got, want)
+	}
+}
+
+func TestGroupsReuse(t *testing.T) {
+	const fname = "TestGroupsReuse"
+	config, cmd := testConfig()
+	config.Exclusive("json", "yaml")
+	opts := []Option{
+		{Type: Bool, Flag: "json", Default: false, Commands: cmd[0]},
+		{Type: Bool, Flag: "yaml", Default: false, Commands: cmd[0]},
+	}
+	if _, err := config.ComposeArgs([]string{"-json"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	config, _ = testConfig()
+	config.Exclusive("json", "yaml")
+	if _, err := config.ComposeArgs([]string{"-yaml"}, opts...); err != nil {
+		t.Errorf("%s: %s", fname, err)
+	}
+}
@@ -0,0 +1,280 @@
+package conf
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Man pages
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// Man writes the roff man page of the command with the given token to
+// w, made from its usage, the header for the default set, along with
+// its sub-commands and options. The options are those given, loaded
+// without parsing any arguments and leaving the Config as it was, else
+// those with which the Config was composed; An error is returned when
+// there are neither.
+//
+//	c.Man(one, os.Stdout, opts...)
+func (c *Config) Man(token CMD, w io.Writer, opts ...Option) error {
+	const fname = "Config.Man"
+	c, err := docConfig(c, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	i, err := commandIndex(c, token)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	if _, err := io.WriteString(w, manPage(c, &c.commands[i])); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	return nil
+}
+
+// ManPages writes the man page of every command into the directory dir,
+// app.1 for the default set and app-cmd.1 for each command, the names
+// of a nested command being joined by hyphens; The options are found
+// as they are by Man.
+//
+//	c.ManPages("man/man1", opts...)
+func (c *Config) ManPages(dir string, opts ...Option) error {
+	const fname = "Config.ManPages"
+	c, err := docConfig(c, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	for i := range c.commands {
+		m := &c.commands[i]
+		path := filepath.Join(dir, manName(c, m)+".1")
+		err := os.WriteFile(path, []byte(manPage(c, m)), 0644)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fname, err)
+		}
+		if v2() {
+			log.Printf("%s: %s: written\n", fname, path)
+		}
+	}
+
+	if v1() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return nil
+}
+
+// docConfig returns the Config from which documentation is written;
+// When options are given, a copy of c into which they are loaded, such
+// that c may yet be composed, else c itself, which must then have been
+// composed.
+func docConfig(c *Config, opts []Option) (*Config, error) {
+	const fname = "docConfig"
+	if len(opts) == 0 {
+		if len(c.all) == 0 {
+			return nil, fmt.Errorf("%s: no options given and the "+
+				"Config has not been composed: %w", fname, errConfig)
+		}
+		return c, nil
+	}
+	d := *c
+	d.all, d.set = nil, nil
+	d.commands = make([]command, len(c.commands))
+	copy(d.commands, c.commands)
+	for i := range d.commands {
+		d.commands[i].options, d.commands[i].seen = nil, nil
+	}
+	// The options are copied, those of a composed Config keeping
+	// their sources.
+	opts = append([]Option(nil), opts...)
+	if err := loadOptions(&d, opts...); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// manName returns the name of the commands man page, the program name
+// followed by the command path joined by hyphens.
+func manName(c *Config, m *command) string {
+	if m.parent < 0 {
+		return progName(c)
+	}
+	return progName(c) + "-" + cmdPath(c, m, "-")
+}
+
+// commandName returns the command as it is called, the program name
+// followed by the command path.
+func commandName(c *Config, m *command) string {
+	if path := cmdPath(c, m, " "); path != "" {
+		return progName(c) + " " + path
+	}
+	return progName(c)
+}
+
+// manPage returns the roff man page of the command.
+func manPage(c *Config, m *command) string {
+	var b strings.Builder
+	name := manName(c, m)
+	i, _ := commandIndex(c, m.flag)
+	cmds := children(c, i)
+
+	fmt.Fprintf(&b, ".TH %q 1\n", strings.ToUpper(name))
+	b.WriteString(".SH NAME\n")
+	summary := cmdSummary(m)
+	if m.parent < 0 {
+		summary = m.summary
+		if summary == "" {
+			summary = headerSummary(c.header)
+		}
+	}
+	if summary != "" {
+		fmt.Fprintf(&b, "%s \\- %s\n", name, roffEscape(summary))
+	} else {
+		b.WriteString(name + "\n")
+	}
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n", commandName(c, m))
+	if len(cmds) > 0 {
+		b.WriteString("[\\fIcommand\\fR]\n")
+	}
+	if len(m.options) > 0 {
+		b.WriteString("[\\fIflags\\fR]\n")
+	}
+
+	text := m.usage
+	if m.parent < 0 {
+		text = c.header + text
+	}
+	if text = strings.Trim(text, "\n"); text != "" {
+		b.WriteString(".SH DESCRIPTION\n.nf\n")
+		b.WriteString(roffEscape(text))
+		b.WriteString("\n.fi\n")
+	}
+
+	if len(cmds) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, j := range cmds {
+			fmt.Fprintf(&b, ".TP\n.B %s\n", c.commands[j].cmd)
+			if s := cmdSummary(&c.commands[j]); s != "" {
+				b.WriteString(roffEscape(s) + "\n")
+			}
+		}
+	}
+
+	var env []string
+	if len(m.options) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+		for _, o := range m.options {
+			b.WriteString(manOption(m, o))
+			if e := cmdEnvName(c, m, o); e != "" {
+				env = append(env, fmt.Sprintf(".TP\n.B %s\nsets \\-%s\n",
+					e, o.Flag))
+			}
+		}
+	}
+	if len(env) > 0 {
+		b.WriteString(".SH ENVIRONMENT\n")
+		b.WriteString(strings.Join(env, ""))
+	}
+
+	var see []string
+	if m.parent >= 0 {
+		see = append(see, manName(c, &c.commands[m.parent]))
+	}
+	for _, j := range cmds {
+		see = append(see, manName(c, &c.commands[j]))
+	}
+	if len(see) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		for i, s := range see {
+			see[i] = "\\fB" + s + "\\fR(1)"
+		}
+		b.WriteString(strings.Join(see, ", ") + "\n")
+	}
+	return b.String()
+}
+
+// manOption returns the entry of an option in the OPTIONS section; Its
+// flag, type and usage, followed by its default and any choices,
+// constraints or requirement.
+func manOption(m *command, o *Option) string {
+	var b strings.Builder
+	if isBoolOption(o) {
+		fmt.Fprintf(&b, ".TP\n.B \\-%s\n", o.Flag)
+	} else {
+		fmt.Fprintf(&b, ".TP\n.BI \\-%s \" %s\"\n",
+			o.Flag, strings.TrimPrefix(o.Type.String(), "*"))
+	}
+	var lines []string
+	if o.Usage != "" {
+		lines = append(lines, strings.Split(o.Usage, "\n")...)
+	}
+	if o.Default != nil {
+		if s := valueString(o.Default); s != "" {
+			lines = append(lines, "default: "+s)
+		}
+	}
+	lines = append(lines, optionNotes(m, o)...)
+	for i, l := range lines {
+		lines[i] = roffEscape(l)
+	}
+	if len(lines) > 0 {
+		b.WriteString(strings.Join(lines, "\n.br\n") + "\n")
+	}
+	return b.String()
+}
+
+// optionNotes returns the choices, constraints and requirement of an
+// option within the command m, one line for each.
+func optionNotes(m *command, o *Option) []string {
+	var lines []string
+	if len(o.Choices) > 0 {
+		lines = append(lines, choicesUsage(o.Choices, nil))
+	}
+	if hasConstraints(o) {
+		lines = append(lines, constraintsUsage(o))
+	}
+	if o.Required.Has(m.flag) {
+		lines = append(lines, "required")
+	}
+	return lines
+}
+
+// roffEscape escapes the backslashes of the text and any line that
+// would otherwise be read as a roff request.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = "\\&" + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headerSummary returns a summary taken from the header, its first line
+// that is neither blank nor an upper case heading, less any leading
+// name that is separated from it by a hyphen; A lone name is not a
+// summary.
+func headerSummary(s string) string {
+	for _, l := range strings.Split(s, "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || l == strings.ToUpper(l) {
+			continue
+		}
+		if i := strings.Index(l, " - "); i >= 0 {
+			return strings.TrimSpace(l[i+3:])
+		}
+		if !strings.ContainsAny(l, " \t") {
+			return ""
+		}
+		return l
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package conf
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMan(t *testing.T) {
+	const fname = "TestMan"
+	config := &Config{}
+	def := config.Command("Usage heading", "\n.the default usage\n")
+	remote := config.Node(config.Command("remote", "remote usage")).
+		Summary("manage remotes")
+	add := remote.Command("add", "add usage").Summary("add a remote")
+	rm := remote.Command("rm", "\nremove a remote\n")
+	config.EnvPrefix("app")
+	config.ProgName("app")
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 12, Min: 1, Usage: "a number",
+			Commands: def},
+		{Type: Bool, Flag: "v", Default: false, Usage: "verbose",
+			Commands: Cmds(def, remote.Token())},
+		{Type: String, Flag: "name", Default: "origin", Usage: "the name",
+			Choices: []string{"origin", "upstream"}, Required: add.Token(),
+			Commands: add.Token()},
+		{Type: StringMap, Flag: "l", Default: map[string]string{"b": "2",
+			"a": "1"}, Commands: add.Token()},
+	}
+	app := "app"
+	tests := []struct {
+		token CMD
+		want  []string
+	}{
+		{token: config.commands[0].flag, want: []string{
+			".TH \"" + strings.ToUpper(app) + "\" 1\n",
+			".SH NAME\n" + app + " \\- Usage heading\n",
+			"Usage heading\n\\&.the default usage\n.fi\n",
+			".TP\n.B remote\nmanage remotes\n",
+			".TP\n.BI \\-n \" int\"\na number\n.br\ndefault: 12\n.br\nlimits: min 1\n",
+			".TP\n.B \\-v\nverbose\n",
+			".SH ENVIRONMENT\n.TP\n.B APP_N\nsets \\-n\n",
+			".SH SEE ALSO\n\\fB" + app + "-remote\\fR(1)\n",
+		}},
+		{token: add.Token(), want: []string{
+			".TH \"" + strings.ToUpper(app) + "-REMOTE-ADD\" 1\n",
+			".SH NAME\n" + app + "-remote-add \\- add a remote\n",
+			".SH SYNOPSIS\n.B " + app + " remote add\n[\\fIflags\\fR]\n",
+			"the name\n.br\ndefault: origin\n.br\none of: origin, upstream\n.br\nrequired\n",
+			"default: a=1,b=2\n",
+			".B APP_REMOTE_ADD_NAME\n",
+			".SH SEE ALSO\n\\fB" + app + "-remote\\fR(1)\n",
+		}},
+		{token: remote.Token(), want: []string{
+			".SH COMMANDS\n.TP\n.B add\nadd a remote\n.TP\n.B rm\nremove a remote\n",
+		}},
+		{token: rm.Token(), want: []string{
+			".SH NAME\n" + app + "-remote-rm \\- remove a remote\n",
+		}},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := config.Man(test.token, &buf, opts...); err != nil {
+			t.Errorf("%s: %s", fname, err)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s: %q not found in %q", fname, want, buf.String())
+			}
+		}
+	}
+	if err := config.Man(cmdBit(10), &bytes.Buffer{}, opts...); err == nil {
+		t.Errorf("%s: expected an error", fname)
+	}
+
+	// Without options the Config must have been composed, which the
+	// loading of options by Man does not prevent.
+	if err := config.Man(def, &bytes.Buffer{}); !errors.Is(err, errConfig) {
+		t.Errorf("%s: received %v expected %v", fname, err, errConfig)
+	}
+	if _, err := config.ComposeArgs([]string{"-n", "2"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if err := config.Man(def, &bytes.Buffer{}); err != nil {
+		t.Errorf("%s: %s", fname, err)
+	}
+	if err := config.Man(def, &bytes.Buffer{}, opts...); err != nil ||
+		!strings.Contains(config.Explain(), "command line") {
+		t.Errorf("%s: received %q (%v)", fname, config.Explain(), err)
+	}
+}
+
+func TestManPages(t *testing.T) {
+	const fname = "TestManPages"
+	config, cmd := testConfig("remote", "remote add")
+	config.ProgName("app")
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 12, Commands: cmd[0]},
+		{Type: String, Flag: "name", Default: "", Commands: cmd[2]},
+	}
+	dir := t.TempDir()
+	if err := config.ManPages(dir, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	for _, name := range []string{"app", "app-remote", "app-remote-add"} {
+		if _, err := os.Stat(filepath.Join(dir, name+".1")); err != nil {
+			t.Errorf("%s: %s", fname, err)
+		}
+	}
+}
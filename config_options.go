@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"regexp"
 	"time"
 )
 
@@ -31,6 +32,38 @@ type Option struct {
 	// Var is used to pass data by reference into the 'Var' group of
 	// flag types.
 	Var interface{}
+	// Separator splits the values of the slice and map types, a
+	// single flag may contain several separated values, repeated
+	// flags appending their values. A comma is used when left empty.
+	Separator string
+	// Duplicate is the policy of a map type for a key that is given
+	// more than once, by default the last value given is kept.
+	Duplicate KeyPolicy
+	// Choices when given are the only values that the option will
+	// accept, the elements of a slice each being one of them; The
+	// choices are listed in the usage output.
+	Choices []string
+	// Min and Max limit the value of the numeric types, each must
+	// be of the same type as the Default when given.
+	Min, Max interface{}
+	// Pattern is a regular expression that the value of a string, or
+	// each element of a string slice, must match.
+	Pattern string
+	// MinLen and MaxLen limit the number of characters in a string,
+	// or of elements in a slice or map, when not zero.
+	MinLen, MaxLen int
+	// NonEmpty requires that a string, slice or map is not empty.
+	NonEmpty bool
+	// pattern is the compiled Pattern.
+	pattern *regexp.Regexp
+	// Complete returns the shell completion candidates for the
+	// options value that begin with prefix, the Config holding the
+	// values given so far; Choices are used when it is nil.
+	Complete func(prefix string, c *Config) []string
+	// Hint is the shell completion used for the options value when
+	// there are no other candidates, by default file names for the
+	// string types that have neither Choices nor Complete.
+	Hint CompleteHint
 	// Usage is the usage text that is displayed in help output when
 	// the -help -h flags are used or a flag parsing error occurs.
 	Usage string
@@ -41,10 +74,24 @@ type Option struct {
 	// Default data, is the default data to be used in the case that
 	// the flag is not called.
 	Default interface{}
-	// Commands is a bitmask, constructed from the bit flags
-	// assigned to the Option on its creation, defining which
+	// Env is the name of an environment variable from which the
+	// option takes its value when it is set, overriding the Default
+	// and being overridden by the command line. If left empty the
+	// name is derived from the Config's environment prefix, if one
+	// has been set.
+	Env string
+	// src records the source from which the options value was
+	// last set.
+	src Source
+	// Commands is the set of command tokens, constructed from the
+	// tokens assigned to the Option on its creation, defining which
 	// command sets the Option should appear within.
 	Commands CMD
+	// Required is the set of commands in which the option must be
+	// given a value by a source other than its Default, Compose
+	// returning a RequiredError when it is not; It must be a subset of
+	// Commands.
+	Required CMD
 	// err stores any error that the option may have triggered
 	// during its setup, allowing for errors to be returned at a
 	// later time than when the options are being created.
@@ -63,12 +110,15 @@ func loadOptions(c *Config, opts ...Option) error {
 
 	for i := range opts {
 		opt := &opts[i]
+		// The options may have been composed before, their source
+		// is that of this composition alone.
+		opt.src = Source{}
 		c.all = append(c.all, opt)
 		opts[i] = errCheckOption(c, *opt)
 		for j, cmd := range c.commands {
 			// If the command is in an options set, then
 			// save a pointer to the option in that command.
-			if cmd.flag&opt.Commands != 0 {
+			if cmd.flag.Has(opt.Commands) {
 				c.commands[j].options = append(
 					c.commands[j].options, opt)
 			}
@@ -109,6 +159,18 @@ func errCheckOption(c *Config, cmd Option) Option {
 		cmd.err = fmt.Errorf("%s: %s: %w", fname, cmd.Flag, err)
 		c.errs = fmt.Errorf("%s%w", temperr, cmd.err)
 	}
+	if err := checkConstraints(&cmd); err != nil {
+		cmd.err = fmt.Errorf("%s: %s: %w", fname, cmd.Flag, err)
+		c.errs = fmt.Errorf("%s%w", temperr, cmd.err)
+	}
+	if err := checkRequired(cmd); err != nil {
+		cmd.err = fmt.Errorf("%s: %s: %w", fname, cmd.Flag, err)
+		c.errs = fmt.Errorf("%s%w", temperr, cmd.err)
+	}
+	if err := checkChoices(cmd); err != nil {
+		cmd.err = fmt.Errorf("%s: %s: %w", fname, cmd.Flag, err)
+		c.errs = fmt.Errorf("%s%w", temperr, cmd.err)
+	}
 	if err := checkCmd(c, cmd); err != nil {
 		cmd.err = fmt.Errorf("%s: %s: %w", fname, cmd.Flag, err)
 		c.errs = fmt.Errorf("%s%w", temperr, cmd.err)
@@ -132,7 +194,7 @@ func checkFlag(c *Config, o *Option) error {
 		// If the option flag has already been registered on the
 		// current subcommand, we return an error. Duplicate flags
 		// on a differing sub-commands are OK.
-		if set.flag&o.Commands != 0 {
+		if set.flag.Has(o.Commands) {
 			if set.seen.find(o.Flag) {
 				return fmt.Errorf("%s: %w", fname, errDuplicate)
 			}
@@ -195,6 +257,46 @@ func checkDefault(c *Config, o Option) error {
 			return fmt.Errorf("%s: %s: %w",
 				fname, o.Type, errType)
 		}
+	case StringSlice, StringSliceVar:
+		if _, ok := o.Default.([]string); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case IntSlice, IntSliceVar:
+		if _, ok := o.Default.([]int); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case Float64Slice, Float64SliceVar:
+		if _, ok := o.Default.([]float64); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case DurationSlice, DurationSliceVar:
+		if _, ok := o.Default.([]time.Duration); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case StringMap, StringMapVar:
+		if _, ok := o.Default.(map[string]string); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case IntMap, IntMapVar:
+		if _, ok := o.Default.(map[string]int); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case Float64Map, Float64MapVar:
+		if _, ok := o.Default.(map[string]float64); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case DurationMap, DurationMapVar:
+		if _, ok := o.Default.(map[string]time.Duration); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
 	case Nil:
 		return fmt.Errorf("%s: %s: %w",
 			fname, o.Type, errType)
@@ -266,6 +368,54 @@ func checkVar(c *Config, o Option) error {
 			return fmt.Errorf("%s: %s: %w",
 				fname, o.Type, errType)
 		}
+	case StringSlice:
+	case StringSliceVar:
+		if _, ok := o.Var.(*[]string); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case IntSlice:
+	case IntSliceVar:
+		if _, ok := o.Var.(*[]int); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case Float64Slice:
+	case Float64SliceVar:
+		if _, ok := o.Var.(*[]float64); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case DurationSlice:
+	case DurationSliceVar:
+		if _, ok := o.Var.(*[]time.Duration); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case StringMap:
+	case StringMapVar:
+		if _, ok := o.Var.(*map[string]string); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case IntMap:
+	case IntMapVar:
+		if _, ok := o.Var.(*map[string]int); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case Float64Map:
+	case Float64MapVar:
+		if _, ok := o.Var.(*map[string]float64); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
+	case DurationMap:
+	case DurationMapVar:
+		if _, ok := o.Var.(*map[string]time.Duration); !ok {
+			return fmt.Errorf("%s: %s: %w",
+				fname, o.Type, errType)
+		}
 	case Nil:
 		return fmt.Errorf("%s: %s: %w",
 			fname, o.Type, errTypeNil)
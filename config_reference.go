@@ -0,0 +1,169 @@
+package conf
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Reference
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// Reference writes the reference documentation of every command to w,
+// as either markdown or html; Each command with its summary, usage and a
+// table of its options, giving the flag, type, default, the other
+// commands that share the option and its usage. The options are found
+// as they are by Man, those given else those of the composed Config.
+//
+//	c.Reference("markdown", os.Stdout, opts...)
+func (c *Config) Reference(format string, w io.Writer, opts ...Option) error {
+	const fname = "Config.Reference"
+	c, err := docConfig(c, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	var fn func(*Config, *command) string
+	switch format {
+	case "markdown":
+		fn = markdownCommand
+	case "html":
+		fn = htmlCommand
+	default:
+		return fmt.Errorf("%s: %q: unknown format: %w",
+			fname, format, errNotValid)
+	}
+	for i := range c.commands {
+		if _, err := io.WriteString(w, fn(c, &c.commands[i])); err != nil {
+			return fmt.Errorf("%s: %w", fname, err)
+		}
+	}
+	return nil
+}
+
+// referenceRow is the content of one row of an options table.
+type referenceRow struct {
+	flag, typ, def, shared string
+	usage                  []string
+}
+
+// referenceRows returns a row for each of the options of the command.
+func referenceRows(c *Config, m *command) []referenceRow {
+	rows := make([]referenceRow, 0, len(m.options))
+	for _, o := range m.options {
+		r := referenceRow{flag: "-" + o.Flag, typ: o.Type.String()}
+		if o.Default != nil {
+			r.def = valueString(o.Default)
+		}
+		var shared []string
+		for i := range c.commands {
+			if n := &c.commands[i]; n != m && n.flag.Has(o.Commands) {
+				shared = append(shared, commandName(c, n))
+			}
+		}
+		r.shared = strings.Join(shared, ", ")
+		if o.Usage != "" {
+			r.usage = strings.Split(o.Usage, "\n")
+		}
+		r.usage = append(r.usage, optionNotes(m, o)...)
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// referenceSummary returns the summary shown beneath the heading of a
+// command, that with which it is listed in its parent's usage; The
+// default set, which is not listed, has only its own summary.
+func referenceSummary(m *command) string {
+	if m.parent < 0 {
+		return m.summary
+	}
+	return cmdSummary(m)
+}
+
+// markdownCommand returns the markdown section of the command.
+func markdownCommand(c *Config, m *command) string {
+	var b strings.Builder
+	level := strings.Repeat("#", minInt(m.depth+1, 6))
+	fmt.Fprintf(&b, "%s %s\n\n", level, commandName(c, m))
+	if s := referenceSummary(m); s != "" {
+		b.WriteString(s + "\n\n")
+	}
+	text := m.usage
+	if m.parent < 0 {
+		text = c.header + text
+	}
+	if text = strings.Trim(text, "\n"); text != "" {
+		fmt.Fprintf(&b, "```\n%s\n```\n\n", text)
+	}
+	rows := referenceRows(c, m)
+	if len(rows) == 0 {
+		return b.String()
+	}
+	b.WriteString("| Flag | Type | Default | Shared with | Usage |\n")
+	b.WriteString("|------|------|---------|-------------|-------|\n")
+	cell := func(s string) string {
+		return strings.ReplaceAll(s, "|", "\\|")
+	}
+	code := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "`" + cell(s) + "`"
+	}
+	for _, r := range rows {
+		usage := make([]string, len(r.usage))
+		for i, l := range r.usage {
+			usage[i] = cell(strings.TrimSpace(l))
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", code(r.flag),
+			cell(r.typ), code(r.def), cell(r.shared),
+			strings.Join(usage, "<br>"))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// htmlCommand returns the html section of the command.
+func htmlCommand(c *Config, m *command) string {
+	var b strings.Builder
+	e := html.EscapeString
+	level := minInt(m.depth+1, 6)
+	fmt.Fprintf(&b, "<h%d id=\"%s\">%s</h%d>\n", level,
+		e(manName(c, m)), e(commandName(c, m)), level)
+	if s := referenceSummary(m); s != "" {
+		fmt.Fprintf(&b, "<p>%s</p>\n", e(s))
+	}
+	text := m.usage
+	if m.parent < 0 {
+		text = c.header + text
+	}
+	if text = strings.Trim(text, "\n"); text != "" {
+		fmt.Fprintf(&b, "<pre>%s</pre>\n", e(text))
+	}
+	rows := referenceRows(c, m)
+	if len(rows) == 0 {
+		return b.String()
+	}
+	b.WriteString("<table>\n<thead>\n<tr><th>Flag</th><th>Type</th>" +
+		"<th>Default</th><th>Shared with</th><th>Usage</th></tr>\n" +
+		"</thead>\n<tbody>\n")
+	code := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "<code>" + e(s) + "</code>"
+	}
+	for _, r := range rows {
+		usage := make([]string, len(r.usage))
+		for i, l := range r.usage {
+			usage[i] = e(strings.TrimSpace(l))
+		}
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%s</td>"+
+			"<td>%s</td><td>%s</td></tr>\n", code(r.flag), e(r.typ),
+			code(r.def), e(r.shared), strings.Join(usage, "<br>"))
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return b.String()
+}
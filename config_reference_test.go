@@ -0,0 +1,73 @@
+package conf
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReference(t *testing.T) {
+	const fname = "TestReference"
+	config := &Config{}
+	def := config.Command("Usage heading", "\n.the default usage\n")
+	remote := config.Node(config.Command("remote", "remote usage")).
+		Summary("manage remotes")
+	add := remote.Command("add", "add usage").Summary("add a remote")
+	remote.Command("rm", "\nremove a remote\n")
+	config.ProgName("app")
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 12, Min: 1, Usage: "a number",
+			Commands: def},
+		{Type: Bool, Flag: "v", Default: false, Usage: "verbose",
+			Commands: Cmds(def, remote.Token())},
+		{Type: String, Flag: "name", Default: "origin", Usage: "the name",
+			Choices: []string{"origin", "upstream"}, Required: add.Token(),
+			Commands: add.Token()},
+		{Type: StringMap, Flag: "l", Default: map[string]string{"b": "2",
+			"a": "1"}, Commands: add.Token()},
+	}
+	app := "app"
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: "markdown", want: []string{
+			"# " + app + "\n\n```\nUsage heading\n.the default usage\n```\n\n",
+			"| Flag | Type | Default | Shared with | Usage |\n",
+			"| `-n` | int | `12` |  | a number<br>limits: min 1 |\n",
+			"| `-v` | bool | `false` | " + app + " remote | verbose |\n",
+			"## " + app + " remote\n\nmanage remotes\n\n",
+			"### " + app + " remote add\n\nadd a remote\n\n",
+			"| `-name` | string | `origin` |  | the name<br>one of: origin, upstream<br>required |\n",
+			"| `-l` | map[string]string | `a=1,b=2` |  |  |\n",
+			"### app remote rm\n\nremove a remote\n\n",
+		}},
+		{format: "html", want: []string{
+			"<h1 id=\"" + app + "\">" + app + "</h1>\n",
+			"<h3 id=\"" + app + "-remote-add\">" + app + " remote add</h3>\n<p>add a remote</p>\n",
+			"<tr><td><code>-v</code></td><td>bool</td><td><code>false</code></td><td>" +
+				app + "</td><td>verbose</td></tr>\n",
+			"<h3 id=\"" + app + "-remote-rm\">" + app + " remote rm</h3>\n<p>remove a remote</p>\n",
+		}},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := config.Reference(test.format, &buf, opts...); err != nil {
+			t.Errorf("%s: %s: %s", fname, test.format, err)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s: %s: %q not found in %q",
+					fname, test.format, want, buf.String())
+			}
+		}
+	}
+	if err := config.Reference("pdf", &bytes.Buffer{}, opts...); !errors.Is(err, errNotValid) {
+		t.Errorf("%s: received %v expected %v", fname, err, errNotValid)
+	}
+	if err := config.Reference("html", &bytes.Buffer{}); !errors.Is(err, errConfig) {
+		t.Errorf("%s: received %v expected %v", fname, err, errConfig)
+	}
+}
@@ -0,0 +1,89 @@
+package conf
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Required
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// RequiredError is the error returned by Compose when options that are
+// required by the running command have not been given a value.
+type RequiredError struct {
+	// Command is the path of the running command, empty for the
+	// default set.
+	Command string
+	// Flags are the flags of all of the missing options.
+	Flags []string
+}
+
+func (e *RequiredError) Error() string {
+	flags := make([]string, len(e.Flags))
+	for i, f := range e.Flags {
+		flags[i] = "-" + f
+	}
+	s := "missing required flags: " + strings.Join(flags, ", ")
+	if e.Command != "" {
+		s = e.Command + ": " + s
+	}
+	return s
+}
+
+// Unwrap returns ErrRequired, such that a RequiredError may be
+// identified by errors.Is.
+func (e *RequiredError) Unwrap() error {
+	return ErrRequired
+}
+
+// checkRequired checks that an option is only required by commands that
+// it belongs to.
+func checkRequired(o Option) error {
+	const fname = "checkRequired"
+	if o.Required.Or(o.Commands) != o.Commands {
+		return fmt.Errorf("%s: required outside of its commands: %w",
+			fname, errNotValid)
+	}
+	return nil
+}
+
+// requiredFlag marks the flag of a required option within its usage.
+func requiredFlag(c *Config, o *Option) error {
+	const fname = "requiredFlag"
+	f := c.flagSet.Lookup(o.Flag)
+	if f == nil {
+		return fmt.Errorf("%s: %s: %w", fname, o.Flag, errNoFlag)
+	}
+	f.Usage = "(required) " + f.Usage
+	return nil
+}
+
+// runRequired returns a RequiredError listing every option that is
+// required by the running command and has taken its default value, not
+// having been set by any other source.
+func runRequired(c *Config) error {
+	const fname = "runRequired"
+	var missing options
+	for _, o := range c.set.options {
+		if o.Required.Has(c.set.flag) && o.src.Kind == FromDefault {
+			missing = append(missing, o)
+		}
+	}
+	if len(missing) > 0 {
+		err := &RequiredError{Command: cmdPath(c, c.set, " ")}
+		for _, o := range missing {
+			err.Flags = append(err.Flags, o.Flag)
+			o.err = err
+		}
+		c.errs = err
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+
+	if v2() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package conf
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRequired(t *testing.T) {
+	const fname = "TestRequired"
+	tests := []struct {
+		args   []string
+		env    string
+		flags  []string
+		marked bool
+	}{
+		{args: []string{"-n", "1"}},
+		{args: []string{}, flags: []string{"n"}},
+		{args: []string{"one", "-b"}, flags: []string{"id", "n"},
+			marked: true},
+		{args: []string{"one", "-id", "x"}, flags: []string{"n"},
+			marked: true},
+		{args: []string{"one", "-id", "", "-n", "0"}, marked: true},
+		{args: []string{"two"}},
+		{args: []string{}, env: "2"},
+	}
+	for _, test := range tests {
+		if test.env != "" {
+			t.Setenv("APP_N", test.env)
+		}
+		config, cmd := testConfig("one", "two")
+		config.EnvPrefix("app")
+		opts := []Option{
+			{Type: String, Flag: "id", Default: "",
+				Commands: Cmds(cmd[1], cmd[2]), Required: cmd[1]},
+			{Type: Int, Flag: "n", Default: 0, Commands: Cmds(cmd[0], cmd[1]),
+				Required: Cmds(cmd[0], cmd[1])},
+			{Type: Bool, Flag: "b", Default: false, Commands: cmd[1]},
+		}
+		_, err := config.ComposeArgs(test.args, opts...)
+		// The usage of a required flag is marked in its commands alone.
+		if f := config.flagSet.Lookup("id"); f != nil &&
+			strings.HasPrefix(f.Usage, "(required)") != test.marked {
+			t.Errorf("%s: %v: received %q", fname, test.args, f.Usage)
+		}
+		if test.flags == nil {
+			if err != nil {
+				t.Errorf("%s: %v: %s", fname, test.args, err)
+			}
+			continue
+		}
+		var re *RequiredError
+		if !errors.As(err, &re) || !errors.Is(err, ErrRequired) {
+			t.Errorf("%s: %v: received %v expected %v",
+				fname, test.args, err, ErrRequired)
+			continue
+		}
+		if !reflect.DeepEqual(re.Flags, test.flags) {
+			t.Errorf("%s: %v: received %v expected %v",
+				fname, test.args, re.Flags, test.flags)
+		}
+	}
+}
+
+func TestRequiredError(t *testing.T) {
+	const fname = "TestRequiredError"
+	err := &RequiredError{Command: "remote add", Flags: []string{"a", "b"}}
+	want := "remote add: missing required flags: -a, -b"
+	if err.Error() != want {
+		t.Errorf("%s: received %q expected %q", fname, err, want)
+	}
+}
+
+func TestRequiredInvalid(t *testing.T) {
+	const fname = "TestRequiredInvalid"
+	config, cmd := testConfig("one")
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 0, Commands: cmd[0], Required: cmd[1]},
+	}
+	if _, err := config.ComposeArgs([]string{}, opts...); !errors.Is(err, errConfig) {
+		t.Errorf("%s: received %v expected %v", fname, err, errConfig)
+	}
+}
+
+func TestRequiredReuse(t *testing.T) {
+	const fname = "TestRequiredReuse"
+	config, cmd := testConfig()
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 0, Commands: cmd[0], Required: cmd[0]},
+	}
+	if _, err := config.ComposeArgs([]string{"-n", "1"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	config, _ = testConfig()
+	if _, err := config.ComposeArgs([]string{}, opts...); !errors.Is(err, ErrRequired) {
+		t.Errorf("%s: received %v expected %v", fname, err, ErrRequired)
+	}
+}
@@ -0,0 +1,129 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// Handler is a function that runs a command, args being the arguments
+// that remain once the commands flags have been parsed.
+type Handler func(ctx context.Context, c *Config, args []string) error
+
+// ExitError may be returned by a Handler to set the exit code that is
+// returned by Execute.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Exit codes returned by Execute.
+const (
+	// ExitOK the command ran without error.
+	ExitOK = 0
+	// ExitFailure the commands handler returned an error.
+	ExitFailure = 1
+	// ExitUsage the Config could not be composed, either through a
+	// configuration error, or a usage error made by the user, or no
+	// handler has been defined for the running command.
+	ExitUsage = 2
+)
+
+// Handle attaches a handler to the command with the given token, the
+// handler being called by Execute when the command is run. Any errors
+// are accumulated into the Config in the same manner as Command.
+//
+//	c.Handle(greet, func(ctx context.Context, c *conf.Config, args []string) error {
+//		s, err := c.ValueString("s")
+//		...
+//	})
+func (c *Config) Handle(token CMD, fn Handler) {
+	const fname = "Config.Handle"
+	i, err := commandIndex(c, token)
+	if err != nil {
+		c.errs = fmt.Errorf("%s: %w", fname, err)
+		return
+	}
+	if fn == nil {
+		const event = "nil handler"
+		c.errs = fmt.Errorf("%s: %s: %w", fname, event, errConfig)
+		return
+	}
+	c.commands[i].run = fn
+}
+
+// Execute composes the Config from the command line arguments in
+// os.Args, dispatching the running command to its handler; Errors are
+// written to the Config's error output and mapped to an exit code, that
+// is returned for use with os.Exit.
+//
+//	os.Exit(c.Execute(context.Background(), opts...))
+func (c *Config) Execute(ctx context.Context, opts ...Option) int {
+	var args []string
+	if len(os.Args) > 1 {
+		args = os.Args[1:]
+	}
+	return c.ExecuteArgs(ctx, args, opts...)
+}
+
+// ExecuteArgs composes the Config from the given arguments, which
+// should not contain the program name, and dispatches the running
+// command to its handler, returning an exit code.
+func (c *Config) ExecuteArgs(ctx context.Context, args []string, opts ...Option) int {
+	const fname = "Config.ExecuteArgs"
+	_, err := c.ComposeArgs(args, opts...)
+	if errors.Is(err, ErrHelp) || errors.Is(err, ErrComplete) {
+		return ExitOK
+	}
+	if err != nil {
+		return exitCode(stderr(c), fmt.Errorf("%s: %w", fname, err), ExitUsage)
+	}
+	// The built-in config command has been run by ComposeArgs.
+	if !c.store.IsZero() && c.set.flag == c.store {
+		return ExitOK
+	}
+	if c.set.run == nil {
+		const event = "no handler defined"
+		fmt.Fprintf(stderr(c), "%s: %q: %s\n",
+			fname, cmdPath(c, c.set, " "), event)
+		c.flagSet.Usage()
+		return ExitUsage
+	}
+	if err := c.set.run(ctx, c, c.Args()); err != nil {
+		return exitCode(stderr(c), err, ExitFailure)
+	}
+
+	if v1() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return ExitOK
+}
+
+// exitCode writes the error to w and returns the exit code of an
+// ExitError if the error contains one, else the given code.
+func exitCode(w io.Writer, err error, code int) int {
+	var e *ExitError
+	if errors.As(err, &e) {
+		code = e.Code
+		if e.Err == nil {
+			return code
+		}
+	}
+	fmt.Fprintln(w, err)
+	return code
+}
@@ -0,0 +1,73 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestExecute(t *testing.T) {
+	const fname = "TestExecute"
+	var ran string
+	var got []string
+	tests := []struct {
+		args []string
+		code int
+		ran  string
+	}{
+		{args: []string{"-n", "2"}, code: ExitOK, ran: "def"},
+		{args: []string{"one", "a", "b"}, code: ExitOK, ran: "one"},
+		{args: []string{"fail"}, code: ExitFailure, ran: "fail"},
+		{args: []string{"exit"}, code: 3, ran: "exit"},
+		{args: []string{"none"}, code: ExitUsage},
+		{args: []string{"-n", "x"}, code: ExitUsage},
+		{args: []string{"-h"}, code: ExitOK},
+	}
+	for _, test := range tests {
+		ran, got = "", nil
+		config, cmd := testConfig("one", "fail", "exit", "none")
+		config.SetErrorHandling(ContinueOnError)
+		handler := func(name string, err error) Handler {
+			return func(ctx context.Context, c *Config, args []string) error {
+				if ctx.Value(ctxKey{}) != "ctx" || c != config {
+					t.Errorf("%s: handler arguments", fname)
+				}
+				ran, got = name, args
+				return err
+			}
+		}
+		config.Handle(cmd[0], handler("def", nil))
+		config.Handle(cmd[1], handler("one", nil))
+		config.Handle(cmd[2], handler("fail", errors.New("failed")))
+		config.Handle(cmd[3], handler("exit", &ExitError{Code: 3}))
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 1, Commands: cmd[0]},
+		}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "ctx")
+		code := config.ExecuteArgs(ctx, test.args, opts...)
+		if code != test.code {
+			t.Errorf("%s: %q: received %d expected %d",
+				fname, test.args, code, test.code)
+		}
+		if ran != test.ran {
+			t.Errorf("%s: %q: received %q expected %q",
+				fname, test.args, ran, test.ran)
+		}
+		if ran == "one" && (len(got) != 2 || got[0] != "a") {
+			t.Errorf("%s: received %q expected [a b]", fname, got)
+		}
+	}
+}
+
+func TestHandleUnknownCMD(t *testing.T) {
+	const fname = "TestHandleUnknownCMD"
+	config, _ := testConfig()
+	config.Handle(cmdBit(3), func(context.Context, *Config, []string) error {
+		return nil
+	})
+	if !errors.Is(config.errs, ErrUnknownCMD) {
+		t.Errorf("%s: %s", fname, config.errs)
+	}
+}
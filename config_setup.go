@@ -1,10 +1,8 @@
 package conf
 
 import (
-	"errors"
 	"fmt"
 	"log"
-	"os"
 )
 
 func configPreconditions(c *Config, opts ...Option) error {
@@ -28,40 +26,38 @@ func configPreconditions(c *Config, opts ...Option) error {
 }
 
 // ascertainCmdSet sets the program operating mode, either the default or that
-// specified by the first argument if it is not a flag.
-func ascertainCmdSet(c *Config) (set CMD, err error) {
+// specified by the leading arguments that are not flags, walking the
+// command tree from the default set for as long as they name a
+// sub-command.
+func ascertainCmdSet(c *Config, args []string) (set CMD, err error) {
 	const fname = "ascertainCmdSet"
-	if len(os.Args) > 1 && os.Args[1][0] != '-' {
-		if set, err = setCommand(c, os.Args[1]); err != nil {
+	if len(c.commands) == 0 {
+		const event = "empty command set"
+		err = fmt.Errorf("%s: %s", fname, event)
+		return
+	}
+	m := 0
+	for _, arg := range args {
+		if len(arg) == 0 || arg[0] == '-' {
+			break
+		}
+		i, err := findCommand(c, m, arg)
+		if err != nil {
 			// Avoid an error in the case when a argument is
 			// required and no flags nor operating commands
 			// have been given, this should not raise an
 			// error.
 			if v2() {
-				log.Printf("%s: default: set defined, file: %s\n",
-					fname, os.Args[1])
-			}
-			c.set = &c.commands[0]
-			set = 1
-			if errors.Is(err, ErrUnknownCMD) {
-				err = nil
+				log.Printf("%s: %s: not a command\n", fname, arg)
 			}
-			return
+			break
 		}
-		if v2() {
-			log.Printf("%s: %s: set defined\n", fname, os.Args[1])
-		}
-		return
-	}
-	if len(c.commands) == 0 {
-		const event = "empty command set"
-		err = fmt.Errorf("%s: %s", fname, event)
-		return
+		m = i
 	}
-	c.set = &c.commands[0]
-	set = 1
+	c.set = &c.commands[m]
+	set = c.set.flag
 	if v2() {
-		log.Printf("%s: default: set defined\n", fname)
+		log.Printf("%s: %q: set defined\n", fname, cmdPath(c, c.set, " "))
 	}
 	return
 }
@@ -0,0 +1,181 @@
+package conf
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// SourceKind names the origin of an options value.
+type SourceKind int
+
+const (
+	// FromDefault the value is the options Default.
+	FromDefault SourceKind = iota
+	// FromFile the value was read from a configuration file, either
+	// one declared with Files or that of the config command.
+	FromFile
+	// FromEnv the value was read from an environment variable.
+	FromEnv
+	// FromArgs the value was given on the command line.
+	FromArgs
+)
+
+func (k SourceKind) String() string {
+	switch k {
+	case FromDefault:
+		return "default"
+	case FromFile:
+		return "file"
+	case FromEnv:
+		return "environment"
+	case FromArgs:
+		return "command line"
+	default:
+		return "error: unknown source"
+	}
+}
+
+// Source describes where the value of an option came from.
+type Source struct {
+	Kind SourceKind
+	// Path and Line locate the value within a configuration file.
+	Path string
+	Line int
+	// Name is the name of the environment variable.
+	Name string
+	// Index is the index of the flag within the arguments that were
+	// parsed, os.Args[1:] when using Compose.
+	Index int
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case FromFile:
+		return s.Kind.String() + " " + s.Path + ":" + strconv.Itoa(s.Line)
+	case FromEnv:
+		return s.Kind.String() + " " + s.Name
+	case FromArgs:
+		return s.Kind.String() + " argument " + strconv.Itoa(s.Index)
+	default:
+		return s.Kind.String()
+	}
+}
+
+// Source returns the source from which the value of the given option
+// was taken, within the current running command set.
+func (c Config) Source(flag string) (Source, error) {
+	const fname = "Source"
+	o, err := lookup(&c, flag)
+	if err != nil {
+		return Source{}, fmt.Errorf("%s: %w", fname, err)
+	}
+	return o.src, nil
+}
+
+// Explain returns a description of every option within the current
+// running command set, its value and where that value came from, in the
+// order in which the options were defined.
+func (c Config) Explain() string {
+	if c.set == nil || c.flagSet == nil {
+		panic("Config.set is nil, have you run Config.Compose?")
+	}
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 8, 2, ' ', 0)
+	for _, o := range c.set.options {
+		value := "<nil>"
+		if f := c.flagSet.Lookup(o.Flag); f != nil {
+			value = f.Value.String()
+		}
+		fmt.Fprintf(w, "-%s\t%s\t%s\n", o.Flag, value, o.src)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// Changed returns true if the flag was set on the command line of the
+// current running command set, false if its value is that of the
+// Default or was taken from the environment or a configuration file,
+// even if that value is the same.
+func (c Config) Changed(name string) bool {
+	if c.flagSet == nil {
+		panic("Config.flagSet is nil, have you run Config.Compose?")
+	}
+	var changed bool
+	c.flagSet.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			changed = true
+		}
+	})
+	return changed
+}
+
+// Visit calls fn for every option of the current running command set
+// that was set on the command line, in the lexicographical order of
+// their flags.
+func (c Config) Visit(fn func(Option)) {
+	if c.flagSet == nil {
+		panic("Config.flagSet is nil, have you run Config.Compose?")
+	}
+	c.flagSet.Visit(func(f *flag.Flag) {
+		if o := c.set.options.find(f.Name); o != nil {
+			fn(*o)
+		}
+	})
+}
+
+// argsSource records the command line as the source of every flag that
+// has been set by parsing the arguments, along with the index of the
+// last argument that set it. offset is the index of the first argument
+// that was parsed; The arguments are walked as the flagset parses them,
+// the argument that follows a flag that is neither boolean nor given
+// its value with '=' being that flags value, whatever its form.
+func argsSource(c *Config, args []string, offset int) {
+	index := make(map[string]int)
+	for i := offset; i < len(args); i++ {
+		name := argName(args[i])
+		if name == "" || args[i] == "--" {
+			break
+		}
+		index[name] = i
+		if strings.IndexByte(args[i], '=') >= 0 {
+			continue
+		}
+		f := c.flagSet.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok ||
+			!b.IsBoolFlag() {
+			i++
+		}
+	}
+	c.flagSet.Visit(func(f *flag.Flag) {
+		o := c.set.options.find(f.Name)
+		if o == nil {
+			return
+		}
+		o.src = Source{Kind: FromArgs, Index: -1}
+		if i, ok := index[f.Name]; ok {
+			o.src.Index = i
+		}
+	})
+}
+
+// argName returns the flag name of an argument, an empty string if the
+// argument is not a flag.
+func argName(arg string) string {
+	if len(arg) < 2 || arg[0] != '-' {
+		return ""
+	}
+	name := arg[1:]
+	if name[0] == '-' {
+		name = name[1:]
+	}
+	if i := strings.IndexByte(name, '='); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
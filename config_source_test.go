@@ -0,0 +1,151 @@
+package conf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSource(t *testing.T) {
+	const fname = "TestSource"
+	path := writeFile(t, "app.toml", "# team\n[one]\ns = \"file\"\nd = \"2s\"\n")
+	t.Setenv("APP_ONE_D", "3s")
+	compose := func(args []string, paths ...string) *Config {
+		config, cmd := testConfig("one")
+		config.EnvPrefix("APP")
+		config.Files(paths...)
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 12, Commands: Cmds(cmd[0], cmd[1])},
+			{Type: String, Flag: "s", Default: "default", Commands: cmd[1]},
+			{Type: Duration, Flag: "d", Default: time.Second, Commands: cmd[1]},
+			{Type: Bool, Flag: "b", Default: false, Commands: cmd[1]},
+		}
+		if _, err := config.ComposeArgs(args, opts...); err != nil {
+			t.Fatalf("%s: %s", fname, err)
+		}
+		return config
+	}
+	config := compose([]string{"one", "-b", "-n=1", "--n", "2", "arg"}, path)
+	tests := []struct {
+		flag string
+		want Source
+	}{
+		{flag: "n", want: Source{Kind: FromArgs, Index: 3}},
+		{flag: "b", want: Source{Kind: FromArgs, Index: 1}},
+		{flag: "s", want: Source{Kind: FromFile, Path: path, Line: 3}},
+		{flag: "d", want: Source{Kind: FromEnv, Name: "APP_ONE_D"}},
+	}
+	for _, test := range tests {
+		src, err := config.Source(test.flag)
+		if err != nil {
+			t.Errorf("%s: %s", fname, err)
+		}
+		if src != test.want {
+			t.Errorf("%s: %s: received %q expected %q",
+				fname, test.flag, src, test.want)
+		}
+	}
+	if _, err := config.Source("x"); !errors.Is(err, errNoFlag) {
+		t.Errorf("%s: %s", fname, err)
+	}
+
+	exp := config.Explain()
+	for _, want := range []string{
+		"-n  2     command line argument 3\n",
+		"-s  file  file " + path + ":3\n",
+		"-d  3s    environment APP_ONE_D\n",
+	} {
+		if !strings.Contains(exp, want) {
+			t.Errorf("%s: %q not found in %q", fname, want, exp)
+		}
+	}
+
+	// A value that looks like a flag is the value of the flag before.
+	config = compose([]string{"one", "-n", "1", "-s", "-n", "-d", "-1s"})
+	for flag, want := range map[string]int{"n": 1, "s": 3, "d": 5} {
+		if src, _ := config.Source(flag); src.Index != want {
+			t.Errorf("%s: %s: received %d expected %d",
+				fname, flag, src.Index, want)
+		}
+	}
+
+	config = compose(nil)
+	if src, _ := config.Source("n"); src.Kind != FromDefault {
+		t.Errorf("%s: received %q expected \"default\"", fname, src)
+	}
+	if _, err := config.Source("s"); !errors.Is(err, ErrNotInCurrentSet) {
+		t.Errorf("%s: %s", fname, err)
+	}
+}
+
+func TestChanged(t *testing.T) {
+	const fname = "TestChanged"
+	path := writeFile(t, "app.toml", "[one]\ns = \"file\"\n")
+	t.Setenv("APP_ONE_D", "3s")
+	config, cmd := testConfig("one")
+	config.EnvPrefix("APP")
+	config.Files(path)
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 12, Commands: Cmds(cmd[0], cmd[1])},
+		{Type: String, Flag: "s", Default: "default", Commands: cmd[1]},
+		{Type: Duration, Flag: "d", Default: time.Second, Commands: cmd[1]},
+		{Type: Bool, Flag: "b", Default: false, Commands: cmd[1]},
+	}
+	args := []string{"one", "-n", "12", "-b"}
+	if _, err := config.ComposeArgs(args, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	tests := []struct {
+		flag string
+		want bool
+	}{
+		{flag: "n", want: true},
+		{flag: "b", want: true},
+		{flag: "s", want: false},
+		{flag: "d", want: false},
+		{flag: "x", want: false},
+	}
+	for _, test := range tests {
+		if got := config.Changed(test.flag); got != test.want {
+			t.Errorf("%s: %s: received %t expected %t",
+				fname, test.flag, got, test.want)
+		}
+	}
+	var flags []string
+	config.Visit(func(o Option) {
+		flags = append(flags, o.Flag)
+	})
+	if strings.Join(flags, " ") != "b n" {
+		t.Errorf("%s: received %q expected \"b n\"", fname, flags)
+	}
+}
+
+func TestSourceReuse(t *testing.T) {
+	const fname = "TestSourceReuse"
+	config, cmd := testConfig()
+	opts := []Option{
+		{Type: Int, Flag: "n", Default: 12, Commands: cmd[0]},
+		{Type: String, Flag: "s", Default: "default", Commands: cmd[0]},
+	}
+	if _, err := config.ComposeArgs([]string{"-n", "1"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if !config.Changed("n") || !strings.Contains(config.Explain(), "command line") {
+		t.Fatalf("%s: received %q", fname, config.Explain())
+	}
+
+	// A second Config composed from the same options.
+	config, _ = testConfig()
+	if _, err := config.ComposeArgs([]string{"-s", "x"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if config.Changed("n") || !config.Changed("s") {
+		t.Errorf("%s: received %t %t expected false true",
+			fname, config.Changed("n"), config.Changed("s"))
+	}
+	want := "-n  12  default\n-s  x   command line argument 0\n"
+	if got := config.Explain(); got != want {
+		t.Errorf("%s: received %q expected %q", fname, got, want)
+	}
+}
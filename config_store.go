@@ -0,0 +1,346 @@
+package conf
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// storeCmd is the token used on the command line to call the built-in
+// config command.
+const storeCmd = "config"
+
+// storeUsage is the help output of the built-in config command.
+const storeUsage = `
+COMMAND
+        config get [cmd.]flag
+        config set [cmd.]flag value
+        config unset [cmd.]flag
+        config list
+
+        Records option values that are used in place of their defaults
+        whenever the program is run, flags given on the command line
+        take precedence over recorded values. Options of the default
+        command set are named by their flag alone, those of a command
+        are prefixed by the command path, its names joined by dots.
+
+`
+
+// Persist registers the built-in 'config' command, through which the
+// user may record option values to a configuration file, the recorded
+// values then being used as the options defaults on all subsequent
+// runs of the program.
+//
+//	app config set write.n 36
+//	app config get write.n
+//	app config unset write.n
+//	app config list
+//
+// The file is kept in the users configuration directory, under the
+// given application name, $XDG_CONFIG_HOME/app/config on unix systems.
+// The returned token is that of the config command.
+//
+// The config command is run by Compose itself, set and unset writing
+// the file and get and list writing to the Config's output before it
+// returns; The program need do no more than exit when the returned
+// token is that of the config command.
+func (c *Config) Persist(app string) CMD {
+	const fname = "Config.Persist"
+	if app == "" {
+		const event = "empty application name"
+		c.errs = fmt.Errorf("%s: %s: %w", fname, event, errConfig)
+		return CMD{}
+	}
+	if len(c.commands) == 0 {
+		const event = "the default command must be registered first"
+		c.errs = fmt.Errorf("%s: %s: %w", fname, event, errConfig)
+		return CMD{}
+	}
+	if !c.store.IsZero() {
+		const event = "config command already registered"
+		c.errs = fmt.Errorf("%s: %s: %w", fname, event, errConfig)
+		return CMD{}
+	}
+	c.app = app
+	c.store = c.Command(storeCmd, storeUsage)
+	if !c.store.IsZero() {
+		c.Node(c.store).Summary("record option values used in place of defaults")
+	}
+	return c.store
+}
+
+// storePath returns the path of the configuration file.
+func storePath(c *Config) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, c.app, storeCmd), nil
+}
+
+// entry is a single recorded value from the configuration file.
+type entry struct {
+	key   string
+	value string
+	// line is the line of the file on which the entry was found.
+	line int
+}
+
+// readStore reads all entries from the configuration file, a missing
+// file is not an error.
+func readStore(path string) ([]entry, error) {
+	const fname = "readStore"
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	defer f.Close()
+	var entries []entry
+	scan := bufio.NewScanner(f)
+	for n := 1; scan.Scan(); n++ {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i < 1 {
+			return nil, fmt.Errorf("%s: %s:%d: %w",
+				fname, path, n, errNotValid)
+		}
+		entries = append(entries, entry{
+			key:   strings.TrimSpace(line[:i]),
+			value: line[i+1:],
+			line:  n,
+		})
+	}
+	if err := scan.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	return entries, nil
+}
+
+// writeStore writes the entries to the configuration file, sorted by
+// their key, creating the file and its directory if required.
+func writeStore(path string, entries []entry) error {
+	const fname = "writeStore"
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s=%s\n", e.key, e.value)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	return nil
+}
+
+// storeKey returns the key under which an options value is recorded
+// for the command with the given path, its names joined by dots.
+func storeKey(path, flag string) string {
+	if path == "" {
+		return flag
+	}
+	return path + "." + flag
+}
+
+// storeOption resolves a key into the command and the option that it
+// refers to, returning an error if either does not exist. The command
+// with the longest path that prefixes the key is used.
+func storeOption(c *Config, key string) (*command, *Option, error) {
+	const fname = "storeOption"
+	m, prefix := &c.commands[0], ""
+	for j := range c.commands[1:] {
+		path := cmdPath(c, &c.commands[j+1], ".") + "."
+		if strings.HasPrefix(key, path) && len(path) > len(prefix) {
+			m, prefix = &c.commands[j+1], path
+		}
+	}
+	flag := key[len(prefix):]
+	o := m.options.find(flag)
+	if o == nil {
+		return nil, nil, fmt.Errorf("%s: %q: %w", fname, key, errNoFlag)
+	}
+	return m, o, nil
+}
+
+// checkValue verifies that the value can be converted into the options
+// type, using a scratch flagset such that no user data is altered.
+// Values of the user defined Var type can not be verified here as their
+// flag.Value can not be set without altering it.
+func checkValue(o *Option, value string) error {
+	const fname = "checkValue"
+	if o.Type == Var {
+		return nil
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		const event = "multi line value"
+		return fmt.Errorf("%s: %s: %w", fname, event, errNotValid)
+	}
+	scratch := &Config{flagSet: flag.NewFlagSet(o.Flag, flag.ContinueOnError)}
+	scratch.flagSet.SetOutput(io.Discard)
+	opt := *o
+	if opt.Var != nil {
+		opt.Var = reflect.New(reflect.TypeOf(opt.Var).Elem()).Interface()
+	}
+	if err := flagsToFlagSet(scratch, &opt); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	if err := setFlag(scratch, &opt, value); err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	return nil
+}
+
+// loadStore sets the values recorded in the configuration file for
+// the current running command set, it is run once the flagset has been
+// created and before it is parsed. Recorded values for options that no
+// longer exist are ignored.
+func loadStore(c *Config) error {
+	const fname = "loadStore"
+	if c.store.IsZero() || c.set.flag == c.store {
+		return nil
+	}
+	path, err := storePath(c)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	entries, err := readStore(path)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", fname, err, errConfig)
+	}
+	for _, e := range entries {
+		m, o, err := storeOption(c, e.key)
+		if err != nil || m != c.set {
+			if v2() && err != nil {
+				log.Printf("%s: %s: ignored\n", fname, e.key)
+			}
+			continue
+		}
+		if err := setFlag(c, o, e.value); err != nil {
+			storeErr(c, o, fmt.Errorf("%s: %s:%d: %s: %w",
+				fname, path, e.line, e.key, err))
+			continue
+		}
+		o.src = Source{Kind: FromFile, Path: path, Line: e.line}
+	}
+
+	if c.errs != nil {
+		return fmt.Errorf("%s: %s: %w", fname, c.errs, errConfig)
+	}
+
+	if v2() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return nil
+}
+
+// runStore runs the built-in config command, args being the arguments
+// that follow the command, output is written to w.
+func runStore(c *Config, w io.Writer, args []string) error {
+	const fname = "runStore"
+	const use = "use: get|set|unset|list"
+	fail := func(err error) error {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	if len(args) == 0 {
+		return fail(fmt.Errorf("%s: %w", use, errNoValue))
+	}
+	path, err := storePath(c)
+	if err != nil {
+		return fail(err)
+	}
+	entries, err := readStore(path)
+	if err != nil {
+		return fail(err)
+	}
+	find := func(key string) int {
+		for i, e := range entries {
+			if e.key == key {
+				return i
+			}
+		}
+		return -1
+	}
+	want := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("%s: %d arguments: %w",
+				args[0], n-1, errNoValue)
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "list":
+		if err := want(1); err != nil {
+			return fail(err)
+		}
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s=%s\n", e.key, e.value)
+		}
+		return nil
+	case "get":
+		if err := want(2); err != nil {
+			return fail(err)
+		}
+		i := find(args[1])
+		if i < 0 {
+			return fail(fmt.Errorf("get: %q: %w", args[1], errNotFound))
+		}
+		fmt.Fprintln(w, entries[i].value)
+		return nil
+	case "set":
+		if err := want(3); err != nil {
+			return fail(err)
+		}
+		m, o, err := storeOption(c, args[1])
+		if err != nil {
+			return fail(fmt.Errorf("set: %w", err))
+		}
+		if err := checkValue(o, args[2]); err != nil {
+			return fail(fmt.Errorf("set: %q: %w", args[1], err))
+		}
+		key := storeKey(cmdPath(c, m, "."), o.Flag)
+		if i := find(key); i >= 0 {
+			entries[i].value = args[2]
+		} else {
+			entries = append(entries, entry{key: key, value: args[2]})
+		}
+	case "unset":
+		if err := want(2); err != nil {
+			return fail(err)
+		}
+		i := find(args[1])
+		if i < 0 {
+			return fail(fmt.Errorf("unset: %q: %w", args[1], errNotFound))
+		}
+		entries = append(entries[:i], entries[i+1:]...)
+	default:
+		return fail(fmt.Errorf("%q: %s: %w", args[0], use, errNotValid))
+	}
+
+	if err := writeStore(path, entries); err != nil {
+		return fail(err)
+	}
+
+	if v2() {
+		log.Printf("%s: completed\n", fname)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package conf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestStoreSetGetUnset(t *testing.T) {
+	const fname = "TestStoreSetGetUnset"
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	compose := func(args ...string) *Config {
+		config, cmd := testConfig("one")
+		config.Persist("app")
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 12, Commands: Cmds(cmd[0], cmd[1])},
+			{Type: String, Flag: "s", Default: "default", Commands: cmd[1]},
+		}
+		if _, err := config.ComposeArgs(args, opts...); err != nil {
+			t.Fatalf("%s: %q: %s", fname, args, err)
+		}
+		return config
+	}
+
+	for _, args := range [][]string{
+		{"config", "set", "one.n", "36"},
+		{"config", "set", "n", "24"},
+		{"config", "set", "one.s", "stored"},
+	} {
+		compose(args...)
+	}
+
+	// Recorded values replace the defaults.
+	config := compose("one")
+	if n, _ := config.ValueInt("n"); n != 36 {
+		t.Errorf("%s: received %d expected 36", fname, n)
+	}
+	if s, _ := config.ValueString("s"); s != "stored" {
+		t.Errorf("%s: received %q expected \"stored\"", fname, s)
+	}
+
+	// The command line takes precedence.
+	config = compose("-n", "6")
+	if n, _ := config.ValueInt("n"); n != 6 {
+		t.Errorf("%s: received %d expected 6", fname, n)
+	}
+
+	var buf bytes.Buffer
+	if err := runStore(config, &buf, []string{"get", "one.n"}); err != nil {
+		t.Errorf("%s: %s", fname, err)
+	}
+	if buf.String() != "36\n" {
+		t.Errorf("%s: received %q expected \"36\\n\"", fname, buf.String())
+	}
+	buf.Reset()
+	if err := runStore(config, &buf, []string{"list"}); err != nil {
+		t.Errorf("%s: %s", fname, err)
+	}
+	const list = "n=24\none.n=36\none.s=stored\n"
+	if buf.String() != list {
+		t.Errorf("%s: received %q expected %q", fname, buf.String(), list)
+	}
+
+	if err := runStore(config, &buf, []string{"unset", "one.n"}); err != nil {
+		t.Errorf("%s: %s", fname, err)
+	}
+	err := runStore(config, &buf, []string{"get", "one.n"})
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("%s: %s", fname, err)
+	}
+}
+
+func TestStoreSetErrors(t *testing.T) {
+	const fname = "TestStoreSetErrors"
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tests := []struct {
+		args []string
+		err  error
+	}{
+		{args: []string{"config", "set", "one.n", "twelve"}, err: errType},
+		{args: []string{"config", "set", "one.x", "1"}, err: errNoFlag},
+		{args: []string{"config", "set", "s", "1"}, err: errNoFlag},
+		{args: []string{"config", "set", "one.n"}, err: errNoValue},
+		{args: []string{"config", "del", "one.n"}, err: errNotValid},
+		{args: []string{"config"}, err: errNoValue},
+	}
+	for _, test := range tests {
+		config, cmd := testConfig("one")
+		config.Persist("app")
+		opts := []Option{
+			{Type: Int, Flag: "n", Default: 12, Commands: Cmds(cmd[0], cmd[1])},
+			{Type: String, Flag: "s", Default: "default", Commands: cmd[1]},
+		}
+		_, err := config.ComposeArgs(test.args, opts...)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: %q: %s", fname, test.args, err)
+		}
+	}
+}
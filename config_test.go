@@ -4,15 +4,32 @@ import (
 	"errors"
 	"flag"
 	"io/ioutil"
-	"os"
+	"strings"
 	"testing"
 	"time"
 )
 
 var c = new(Config)
 
-func init() {
-	test = true
+// testConfig returns a new Config with the default command registered,
+// followed by each of the given commands, a nested command being named
+// by its path; The token of every command is returned, the default
+// first.
+func testConfig(cmds ...string) (*Config, []CMD) {
+	config := &Config{}
+	def := config.Node(config.Command("Usage heading", ""))
+	nodes := map[string]Node{"": def}
+	tokens := []CMD{def.Token()}
+	for _, path := range cmds {
+		parent, name := "", path
+		if i := strings.LastIndexByte(path, ' '); i >= 0 {
+			parent, name = path[:i], path[i+1:]
+		}
+		n := nodes[parent].Command(name, "")
+		nodes[path] = n
+		tokens = append(tokens, n.Token())
+	}
+	return config, tokens
 }
 
 type testValue struct {
@@ -153,7 +170,7 @@ func TestConfigValues(t *testing.T) {
 		}
 		switch opt.exp {
 		case "pass":
-			_, err := c.Compose(opts...)
+			_, err := c.ComposeArgs(nil, opts...)
 			if err != nil {
 				t.Errorf("%s: %s: %s", fname, name, err)
 			}
@@ -302,7 +319,7 @@ func TestConfigValues(t *testing.T) {
 			}
 		case "fail":
 			// Both Options and Parse return an errConfig.
-			_, err := c.Compose(opts...)
+			_, err := c.ComposeArgs(nil, opts...)
 			if !errors.Is(err, errConfig) {
 				t.Errorf("%s: %s: %s", fname, name, err)
 			}
@@ -366,14 +383,9 @@ func TestConfigValues(t *testing.T) {
 					t.Errorf("%s: %s: end of case stament reached", fname, name)
 				}
 			case Default:
-				_, typ, err := c.Value("one")
-				switch typ {
-				case Default:
-					if !errors.Is(err, errType) {
-						t.Errorf("%s: %s: %s", fname, name, err)
-					}
-				default:
-					t.Errorf("%s: %s: end of case stament reached", fname, name)
+				_, _, err = c.Value("one")
+				if !errors.Is(err, errType) {
+					t.Errorf("%s: %s: %s", fname, name, err)
 				}
 			case IntVar, Int64Var, UintVar, Uint64Var,
 				Float64Var, StringVar, BoolVar, DurationVar, Var:
@@ -381,7 +393,7 @@ func TestConfigValues(t *testing.T) {
 				t.Errorf("%s: %s: end of case stament reached", fname, name)
 			}
 		case "errNoData", "errNoKey":
-			_, err := c.Compose(opts...)
+			_, err := c.ComposeArgs(nil, opts...)
 			if err != nil {
 				t.Errorf("%s: %s: %s", fname, name, err)
 			}
@@ -446,8 +458,6 @@ func TestParse(t *testing.T) {
 	c = &Config{}
 	cmd := c.Command("one", "the first way")
 	cmd2 := c.Command("two", "the second way")
-	temp := os.Args[1]
-	os.Args[1] = "two"
 	var opts = []Option{
 		{
 			Type:     Int,
@@ -457,7 +467,7 @@ func TestParse(t *testing.T) {
 			Commands: cmd2,
 		},
 	}
-	_, err := c.Compose(opts...)
+	_, err := c.ComposeArgs([]string{"two", "-a", "5", "file"}, opts...)
 	if err != nil {
 		t.Errorf("%s: should not raise an error: %s",
 			fname, err)
@@ -470,7 +480,16 @@ func TestParse(t *testing.T) {
 	if !isInSet(c, cmd) {
 		t.Errorf("%s: not a valid Command token", fname)
 	}
-	os.Args[1] = temp
+	i, err := c.ValueInt("a")
+	if err != nil {
+		t.Errorf("%s: %s", fname, err)
+	}
+	if i != 5 {
+		t.Errorf("%s: received %d expected 5", fname, i)
+	}
+	if c.NArg() != 1 || c.Args()[0] != "file" {
+		t.Errorf("%s: received %q expected [file]", fname, c.Args())
+	}
 }
 
 func TestParseInvalidCmd(t *testing.T) {
@@ -478,8 +497,6 @@ func TestParseInvalidCmd(t *testing.T) {
 	c = &Config{}
 	cmd := c.Command("one", "its like this")
 	cmd2 := c.Command("two", "no its like this")
-	temp := os.Args[1]
-	os.Args[1] = "unknownCmd"
 	var opts = []Option{
 		{
 			Type:     Int,
@@ -489,12 +506,9 @@ func TestParseInvalidCmd(t *testing.T) {
 			Commands: cmd2,
 		},
 	}
-	_, err := c.Compose(opts...)
-	if !errors.Is(err, errNotFound) {
-		t.Errorf("%s: %s", fname, err)
-	}
-	os.Args[1] = temp
-	_, err = c.Compose(opts...)
+	// An unknown command is not an error, the default set is used
+	// and the token is left as an argument.
+	_, err := c.ComposeArgs([]string{"unknownCmd"}, opts...)
 	if err != nil {
 		t.Errorf("%s: should not raise an error: %s",
 			fname, err)
@@ -507,6 +521,58 @@ func TestParseInvalidCmd(t *testing.T) {
 	if !isInSet(c, cmd) {
 		t.Errorf("%s: %s", fname, errNotValid)
 	}
+	if c.NArg() != 1 || c.Args()[0] != "unknownCmd" {
+		t.Errorf("%s: received %q expected [unknownCmd]",
+			fname, c.Args())
+	}
+}
+
+func TestComposeArgsSeveralConfigs(t *testing.T) {
+	const fname = "TestComposeArgsSeveralConfigs"
+	inputs := [][]string{
+		{"two", "-a", "2"},
+		{"two", "-a", "3"},
+		{"-b=4"},
+	}
+	expect := []int{2, 3, 4}
+	for i, args := range inputs {
+		config := &Config{}
+		one := config.Command("one", "the first way")
+		two := config.Command("two", "the second way")
+		var opts = []Option{
+			{
+				Type:     Int,
+				Flag:     "a",
+				Usage:    "like this",
+				Default:  1,
+				Commands: two,
+			},
+			{
+				Type:     Int,
+				Flag:     "b",
+				Usage:    "like that",
+				Default:  1,
+				Commands: one,
+			},
+		}
+		_, err := config.ComposeArgs(args, opts...)
+		if err != nil {
+			t.Errorf("%s: %s", fname, err)
+			continue
+		}
+		key := "a"
+		if config.Cmd() == one {
+			key = "b"
+		}
+		v, err := config.ValueInt(key)
+		if err != nil {
+			t.Errorf("%s: %s", fname, err)
+		}
+		if v != expect[i] {
+			t.Errorf("%s: received %d expected %d",
+				fname, v, expect[i])
+		}
+	}
 }
 
 func TestFlagSetUsageFn(t *testing.T) {
@@ -529,7 +595,7 @@ func TestFlagSetUsageFn(t *testing.T) {
 			Commands: cmd,
 		},
 	}
-	_, err := config.Compose(opts...)
+	_, err := config.ComposeArgs(nil, opts...)
 	if err != nil {
 		t.Errorf("%s: %s", fname, err)
 	}
@@ -537,3 +603,64 @@ func TestFlagSetUsageFn(t *testing.T) {
 	setUsageFn(ioutil.Discard, &config)
 	c.flagSet.Usage()
 }
+
+func TestGet(t *testing.T) {
+	const fname = "TestGet"
+	config := &Config{}
+	def := config.Command("Usage heading", "")
+	one := config.Command("one", "")
+	var iv int
+	value := &testValue{str: "value"}
+	opts := []Option{
+		{Type: Int, Flag: "i", Default: 1, Commands: def},
+		{Type: IntVar, Flag: "iv", Default: 2, Var: &iv, Commands: def},
+		{Type: Duration, Flag: "d", Default: time.Second, Commands: def},
+		{Type: Var, Flag: "v", Value: value, Commands: def},
+		{Type: String, Flag: "s", Default: "", Commands: one},
+	}
+	if _, err := config.ComposeArgs([]string{"-iv", "3"}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if i, err := Get[int](config, "i"); err != nil || i != 1 {
+		t.Errorf("%s: received %d expected 1: %v", fname, i, err)
+	}
+	if i, err := Get[int](config, "iv"); err != nil || i != 3 {
+		t.Errorf("%s: received %d expected 3: %v", fname, i, err)
+	}
+	// The native Var types are read through their Var by Get alone,
+	// Value and the typed Value methods holding no data for them.
+	iv = 4
+	if i, err := Get[int](config, "iv"); err != nil || i != 4 {
+		t.Errorf("%s: received %d expected 4: %v", fname, i, err)
+	}
+	if _, err := config.ValueInt("iv"); !errors.Is(err, errNoData) {
+		t.Errorf("%s: received %v expected %v", fname, err, errNoData)
+	}
+	if _, _, err := config.Value("iv"); !errors.Is(err, errNoData) {
+		t.Errorf("%s: received %v expected %v", fname, err, errNoData)
+	}
+	if d := MustGet[time.Duration](config, "d"); d != time.Second {
+		t.Errorf("%s: received %s expected 1s", fname, d)
+	}
+	if v, err := Get[*testValue](config, "v"); err != nil || v != value {
+		t.Errorf("%s: received %v expected %v: %v", fname, v, value, err)
+	}
+	if v, err := Get[flag.Value](config, "v"); err != nil || v.String() != "value" {
+		t.Errorf("%s: received %v expected value: %v", fname, v, err)
+	}
+	if _, err := Get[string](config, "i"); !errors.Is(err, errType) {
+		t.Errorf("%s: %s", fname, err)
+	}
+	if _, err := Get[string](config, "s"); !errors.Is(err, ErrNotInCurrentSet) {
+		t.Errorf("%s: %s", fname, err)
+	}
+	if _, err := Get[string](config, "x"); !errors.Is(err, errNoFlag) {
+		t.Errorf("%s: %s", fname, err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: MustGet did not panic", fname)
+		}
+	}()
+	MustGet[int](config, "x")
+}
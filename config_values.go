@@ -0,0 +1,312 @@
+package conf
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Slices
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// lister is implemented by those flag values that hold a list, setList
+// replacing the list with the given elements, which are not split by the
+// values separator.
+type lister interface {
+	setList(elements []string) error
+}
+
+// resetter is implemented by those flag values that accumulate their
+// data over several calls to Set, reset marks the start of a new
+// source, the next call to Set replacing the previous sources data.
+type resetter interface {
+	reset()
+}
+
+// sliceValue is the flag.Value of the slice types, each call to Set
+// appends its separated values, the first replacing the default, such
+// that both `-n 1,2,3` and `-n 1 -n 2 -n 3` are accepted.
+type sliceValue[T any] struct {
+	p      *[]T
+	sep    string
+	parse  func(string) (T, error)
+	format func(T) string
+	set    bool
+}
+
+func (s *sliceValue[T]) Set(str string) error {
+	values, err := splitSlice(str, s.sep, s.parse)
+	if err != nil {
+		return err
+	}
+	if !s.set {
+		*s.p = make([]T, 0, len(values))
+		s.set = true
+	}
+	*s.p = append(*s.p, values...)
+	return nil
+}
+
+func (s *sliceValue[T]) String() string {
+	if s == nil || s.p == nil {
+		return ""
+	}
+	return strings.Join(s.elements(), s.sep)
+}
+
+func (s *sliceValue[T]) setList(elements []string) error {
+	values := make([]T, 0, len(elements))
+	for _, e := range elements {
+		v, err := s.parse(e)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+	}
+	*s.p, s.set = values, true
+	return nil
+}
+
+func (s *sliceValue[T]) reset() {
+	s.set = false
+}
+
+// elements returns the string form of each of the values.
+func (s *sliceValue[T]) elements() []string {
+	str := make([]string, len(*s.p))
+	for i, v := range *s.p {
+		str[i] = s.format(v)
+	}
+	return str
+}
+
+// splitSlice parses the values of a separated list, an empty string
+// being an empty list.
+func splitSlice[T any](str, sep string, parse func(string) (T, error)) ([]T, error) {
+	values := make([]T, 0)
+	if str == "" {
+		return values, nil
+	}
+	for _, field := range strings.Split(str, sep) {
+		v, err := parse(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// isSlice returns true if the type is one of the slice types.
+func isSlice(t Type) bool {
+	switch t {
+	case StringSlice, StringSliceVar, IntSlice, IntSliceVar,
+		Float64Slice, Float64SliceVar, DurationSlice, DurationSliceVar:
+		return true
+	}
+	return false
+}
+
+// separator returns the separator of the option, a comma by default.
+func separator(o *Option) string {
+	if o.Separator == "" {
+		return ","
+	}
+	return o.Separator
+}
+
+// flagSlice creates a slice flag for the option within the flagset, its
+// data being stored in the options Var when isVar is set.
+func flagSlice[T any](c *Config, o *Option, isVar bool,
+	parse func(string) (T, error), format func(T) string) error {
+	const def = "Default"
+	const va = "Var"
+	d, ok := o.Default.([]T)
+	if !ok {
+		return fmt.Errorf("%s: %q: %w", o.Type, def, errType)
+	}
+	p := new([]T)
+	if isVar {
+		if p, ok = o.Var.(*[]T); !ok {
+			return fmt.Errorf("%s: %q: %w", o.Type, va, errType)
+		}
+	} else {
+		o.data = p
+	}
+	*p = d
+	v := &sliceValue[T]{p: p, sep: separator(o), parse: parse, format: format}
+	c.flagSet.Var(v, o.Flag, o.Usage)
+	return nil
+}
+
+func parseString(s string) (string, error) { return s, nil }
+func formatString(s string) string         { return s }
+
+func parseFloat64(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+func formatFloat64(f float64) string         { return strconv.FormatFloat(f, 'g', -1, 64) }
+
+/* ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+ *  Maps
+ * ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~ */
+
+// KeyPolicy defines how a map option treats a key that is given more
+// than once by the same source.
+type KeyPolicy int
+
+const (
+	// KeyLast keeps the last value given for a key, the default.
+	KeyLast KeyPolicy = iota
+	// KeyFirst keeps the first value given for a key.
+	KeyFirst
+	// KeyError raises an error when a key is repeated.
+	KeyError
+)
+
+func (k KeyPolicy) String() string {
+	switch k {
+	case KeyLast:
+		return "last"
+	case KeyFirst:
+		return "first"
+	case KeyError:
+		return "error"
+	default:
+		return "error: unknown policy"
+	}
+}
+
+// mapValue is the flag.Value of the map types, each call to Set adds its
+// separated key=value pairs, the first replacing the default, such that
+// both `-l a=1,b=2` and `-l a=1 -l b=2` are accepted.
+type mapValue[T any] struct {
+	p      *map[string]T
+	sep    string
+	policy KeyPolicy
+	parse  func(string) (T, error)
+	format func(T) string
+	set    bool
+}
+
+func (m *mapValue[T]) Set(str string) error {
+	if str == "" {
+		return m.setList(nil)
+	}
+	return m.add(strings.Split(str, m.sep))
+}
+
+func (m *mapValue[T]) String() string {
+	if m == nil || m.p == nil {
+		return ""
+	}
+	return strings.Join(m.elements(), m.sep)
+}
+
+func (m *mapValue[T]) setList(elements []string) error {
+	m.set = false
+	return m.add(elements)
+}
+
+func (m *mapValue[T]) reset() {
+	m.set = false
+}
+
+// add adds the key=value pairs to the map according to its key policy.
+func (m *mapValue[T]) add(pairs []string) error {
+	if !m.set {
+		*m.p = make(map[string]T)
+		m.set = true
+	}
+	for _, pair := range pairs {
+		k, v, err := splitPair(pair, m.parse)
+		if err != nil {
+			return err
+		}
+		if _, ok := (*m.p)[k]; ok {
+			switch m.policy {
+			case KeyFirst:
+				continue
+			case KeyError:
+				return fmt.Errorf("%q: %w", k, errDuplicate)
+			}
+		}
+		(*m.p)[k] = v
+	}
+	return nil
+}
+
+// elements returns the key=value form of each of the pairs, sorted by
+// key.
+func (m *mapValue[T]) elements() []string {
+	keys := make([]string, 0, len(*m.p))
+	for k := range *m.p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = k + "=" + m.format((*m.p)[k])
+	}
+	return keys
+}
+
+// splitPair parses a key=value pair.
+func splitPair[T any](pair string, parse func(string) (T, error)) (string, T, error) {
+	var v T
+	k, s, ok := strings.Cut(pair, "=")
+	k = strings.TrimSpace(k)
+	if !ok || k == "" {
+		return "", v, fmt.Errorf("%q: want key=value: %w", pair, errNotValid)
+	}
+	v, err := parse(strings.TrimSpace(s))
+	if err != nil {
+		return "", v, err
+	}
+	return k, v, nil
+}
+
+// splitMap parses a separated list of key=value pairs, keeping the last
+// value of a repeated key.
+func splitMap[T any](str, sep string, parse func(string) (T, error)) (map[string]T, error) {
+	p := new(map[string]T)
+	m := &mapValue[T]{p: p, sep: sep, parse: parse}
+	if err := m.Set(str); err != nil {
+		return nil, err
+	}
+	return *p, nil
+}
+
+// isMap returns true if the type is one of the map types.
+func isMap(t Type) bool {
+	switch t {
+	case StringMap, StringMapVar, IntMap, IntMapVar,
+		Float64Map, Float64MapVar, DurationMap, DurationMapVar:
+		return true
+	}
+	return false
+}
+
+// flagMap creates a map flag for the option within the flagset, its data
+// being stored in the options Var when isVar is set.
+func flagMap[T any](c *Config, o *Option, isVar bool,
+	parse func(string) (T, error), format func(T) string) error {
+	const def = "Default"
+	const va = "Var"
+	d, ok := o.Default.(map[string]T)
+	if !ok {
+		return fmt.Errorf("%s: %q: %w", o.Type, def, errType)
+	}
+	p := new(map[string]T)
+	if isVar {
+		if p, ok = o.Var.(*map[string]T); !ok {
+			return fmt.Errorf("%s: %q: %w", o.Type, va, errType)
+		}
+	} else {
+		o.data = p
+	}
+	*p = d
+	v := &mapValue[T]{p: p, sep: separator(o), policy: o.Duplicate,
+		parse: parse, format: format}
+	c.flagSet.Var(v, o.Flag, o.Usage)
+	return nil
+}
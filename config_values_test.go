@@ -0,0 +1,262 @@
+package conf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSlice(t *testing.T) {
+	const fname = "TestSlice"
+	tests := []struct {
+		args []string
+		s    []string
+		n    []int
+		f    []float64
+		d    []time.Duration
+	}{
+		{args: []string{}, s: []string{"a", "b"}, n: []int{1}, f: []float64{}},
+		{args: []string{"-s", "x,y", "-n", "2, 3"},
+			s: []string{"x", "y"}, n: []int{2, 3}, f: []float64{}},
+		{args: []string{"-n", "2", "-n", "3,4", "-f", "1.5:2"},
+			s: []string{"a", "b"}, n: []int{2, 3, 4}, f: []float64{1.5, 2}},
+		{args: []string{"-s", "", "-d", "1s", "-d", "1m"},
+			s: []string{}, n: []int{1}, f: []float64{},
+			d: []time.Duration{time.Second, time.Minute}},
+	}
+	for _, test := range tests {
+		config, cmd := testConfig()
+		opts := []Option{
+			{Type: StringSlice, Flag: "s", Default: []string{"a", "b"},
+				Commands: cmd[0]},
+			{Type: IntSlice, Flag: "n", Default: []int{1}, Commands: cmd[0]},
+			{Type: Float64Slice, Flag: "f", Default: []float64{}, Separator: ":",
+				Commands: cmd[0]},
+			{Type: DurationSlice, Flag: "d", Default: []time.Duration(nil),
+				Commands: cmd[0]},
+		}
+		if _, err := config.ComposeArgs(test.args, opts...); err != nil {
+			t.Errorf("%s: %s", fname, err)
+			continue
+		}
+		s, err := config.ValueStringSlice("s")
+		if err != nil || !reflect.DeepEqual(s, test.s) {
+			t.Errorf("%s: %v: received %q expected %q (%v)",
+				fname, test.args, s, test.s, err)
+		}
+		n, err := config.ValueIntSlice("n")
+		if err != nil || !reflect.DeepEqual(n, test.n) {
+			t.Errorf("%s: %v: received %v expected %v (%v)",
+				fname, test.args, n, test.n, err)
+		}
+		f, err := Get[[]float64](config, "f")
+		if err != nil || !reflect.DeepEqual(f, test.f) {
+			t.Errorf("%s: %v: received %v expected %v (%v)",
+				fname, test.args, f, test.f, err)
+		}
+		d, err := config.ValueDurationSlice("d")
+		if err != nil || !reflect.DeepEqual(d, test.d) {
+			t.Errorf("%s: %v: received %v expected %v (%v)",
+				fname, test.args, d, test.d, err)
+		}
+	}
+}
+
+func TestSliceVar(t *testing.T) {
+	const fname = "TestSliceVar"
+	t.Setenv("APP_N", "5,6")
+	var n []int
+	config := &Config{}
+	def := config.Command("Usage heading", "")
+	config.EnvPrefix("app")
+	opts := []Option{
+		{Type: IntSliceVar, Flag: "n", Default: []int{1}, Var: &n,
+			Commands: def},
+	}
+	if _, err := config.ComposeArgs([]string{}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if !reflect.DeepEqual(n, []int{5, 6}) {
+		t.Errorf("%s: received %v expected [5 6]", fname, n)
+	}
+}
+
+func TestSliceErrors(t *testing.T) {
+	const fname = "TestSliceErrors"
+	var n []int
+	tests := []struct {
+		o   Option
+		err error
+	}{
+		{o: Option{Type: IntSlice, Flag: "n", Default: 1}, err: errConfig},
+		{o: Option{Type: IntSlice, Flag: "n", Default: []string{}}, err: errConfig},
+		{o: Option{Type: IntSliceVar, Flag: "n", Default: []int{},
+			Var: n}, err: errConfig},
+	}
+	for _, test := range tests {
+		config := &Config{}
+		test.o.Commands = config.Command("Usage heading", "")
+		_, err := config.ComposeArgs([]string{}, test.o)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: %s: received %v expected %v",
+				fname, test.o.Type, err, test.err)
+		}
+	}
+}
+
+func TestSliceFile(t *testing.T) {
+	const fname = "TestSliceFile"
+	tests := []struct {
+		name, data string
+		s          []string
+		n          []int
+	}{
+		{name: "app.json", data: `{"s": ["x", "y"], "n": [2, 3]}`,
+			s: []string{"x", "y"}, n: []int{2, 3}},
+		{name: "app.toml", data: "s = []\nn = [2, 3]\n",
+			s: []string{}, n: []int{2, 3}},
+		{name: "app.yaml", data: "s: [x, 'y,z']\nn:\n  - 2\n  - 3\n",
+			s: []string{"x", "y,z"}, n: []int{2, 3}},
+		{name: "app.ini", data: "s = x,y\nn = 2,3\n",
+			s: []string{"x", "y"}, n: []int{2, 3}},
+	}
+	compose := func(name, data string) (*Config, error) {
+		config, cmd := testConfig()
+		config.Files(writeFile(t, name, data))
+		opts := []Option{
+			{Type: StringSlice, Flag: "s", Default: []string{"a", "b"},
+				Commands: cmd[0]},
+			{Type: IntSlice, Flag: "n", Default: []int{1}, Commands: cmd[0]},
+			{Type: Float64Slice, Flag: "f", Default: []float64{}, Separator: ":",
+				Commands: cmd[0]},
+			{Type: DurationSlice, Flag: "d", Default: []time.Duration(nil),
+				Commands: cmd[0]},
+		}
+		_, err := config.ComposeArgs([]string{}, opts...)
+		return config, err
+	}
+	for _, test := range tests {
+		config, err := compose(test.name, test.data)
+		if err != nil {
+			t.Errorf("%s: %s: %s", fname, test.name, err)
+			continue
+		}
+		s, _ := config.ValueStringSlice("s")
+		n, _ := config.ValueIntSlice("n")
+		if !reflect.DeepEqual(s, test.s) || !reflect.DeepEqual(n, test.n) {
+			t.Errorf("%s: %s: received %q %v expected %q %v",
+				fname, test.name, s, n, test.s, test.n)
+		}
+	}
+
+	// Arrays of the wrong kind, or for a scalar option.
+	for _, data := range []string{`{"n": ["x"]}`, `{"n": [1, "x"]}`} {
+		if _, err := compose("app.json", data); err == nil {
+			t.Errorf("%s: %s: expected an error", fname, data)
+		}
+	}
+	config, cmd := testConfig()
+	config.Files(writeFile(t, "app.json", `{"n": [1]}`))
+	opts := []Option{{Type: Int, Flag: "n", Default: 12, Commands: cmd[0]}}
+	if _, err := config.ComposeArgs([]string{}, opts...); !errors.Is(err, errConfig) {
+		t.Errorf("%s: received %v expected %v", fname, err, errConfig)
+	}
+}
+
+func TestMap(t *testing.T) {
+	const fname = "TestMap"
+	tests := []struct {
+		args   []string
+		policy KeyPolicy
+		l      map[string]string
+		err    error
+	}{
+		{args: []string{}, l: map[string]string{"env": "dev"}},
+		{args: []string{"-l", "env=prod", "-l", "team=core"},
+			l: map[string]string{"env": "prod", "team": "core"}},
+		{args: []string{"-l", "env=prod,team=core"},
+			l: map[string]string{"env": "prod", "team": "core"}},
+		{args: []string{"-l", "a=1,a=2"}, l: map[string]string{"a": "2"}},
+		{args: []string{"-l", "a=1", "-l", "a=2"}, policy: KeyFirst,
+			l: map[string]string{"a": "1"}},
+		{args: []string{"-l", "a=b=c"}, l: map[string]string{"a": "b=c"}},
+	}
+	for _, test := range tests {
+		config := &Config{}
+		def := config.Command("Usage heading", "")
+		opts := []Option{
+			{Type: StringMap, Flag: "l", Duplicate: test.policy,
+				Default: map[string]string{"env": "dev"}, Commands: def},
+		}
+		if _, err := config.ComposeArgs(test.args, opts...); err != nil {
+			t.Errorf("%s: %v: %s", fname, test.args, err)
+			continue
+		}
+		l, err := config.ValueStringMap("l")
+		if err != nil || !reflect.DeepEqual(l, test.l) {
+			t.Errorf("%s: %v: received %v expected %v (%v)",
+				fname, test.args, l, test.l, err)
+		}
+	}
+}
+
+func TestMapSources(t *testing.T) {
+	const fname = "TestMapSources"
+	t.Setenv("APP_N", "a=1,b=2")
+	var n map[string]int
+	path := writeFile(t, "app.json", `{"d": ["a=1s", "b=1m"]}`)
+	config := &Config{}
+	def := config.Command("Usage heading", "")
+	config.EnvPrefix("app")
+	config.Files(path)
+	opts := []Option{
+		{Type: IntMapVar, Flag: "n", Default: map[string]int{}, Var: &n,
+			Commands: def},
+		{Type: DurationMap, Flag: "d", Default: map[string]time.Duration{},
+			Commands: def},
+	}
+	if _, err := config.ComposeArgs([]string{}, opts...); err != nil {
+		t.Fatalf("%s: %s", fname, err)
+	}
+	if !reflect.DeepEqual(n, map[string]int{"a": 1, "b": 2}) {
+		t.Errorf("%s: received %v", fname, n)
+	}
+	d, err := Get[map[string]time.Duration](config, "d")
+	want := map[string]time.Duration{"a": time.Second, "b": time.Minute}
+	if err != nil || !reflect.DeepEqual(d, want) {
+		t.Errorf("%s: received %v expected %v (%v)", fname, d, want, err)
+	}
+}
+
+func TestMapKeyPolicy(t *testing.T) {
+	const fname = "TestMapKeyPolicy"
+	tests := []struct {
+		policy KeyPolicy
+		want   map[string]string
+		err    error
+	}{
+		{policy: KeyLast, want: map[string]string{"a": "2"}},
+		{policy: KeyFirst, want: map[string]string{"a": "1"}},
+		{policy: KeyError, err: errDuplicate},
+	}
+	for _, test := range tests {
+		p := new(map[string]string)
+		m := &mapValue[string]{p: p, sep: ",", policy: test.policy,
+			parse: parseString, format: formatString}
+		err := m.Set("a=1,a=2")
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: %s: received %v expected %v",
+				fname, test.policy, err, test.err)
+			continue
+		}
+		if test.err == nil && !reflect.DeepEqual(*p, test.want) {
+			t.Errorf("%s: %s: received %v expected %v",
+				fname, test.policy, *p, test.want)
+		}
+	}
+	m := &mapValue[string]{p: new(map[string]string), parse: parseString}
+	if err := m.Set("novalue"); !errors.Is(err, errNotValid) {
+		t.Errorf("%s: received %v expected %v", fname, err, errNotValid)
+	}
+}
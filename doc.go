@@ -20,8 +20,9 @@ sub command.
 The cmd token is then used when defining an option, instructing the
 package that the option is to be assigned to the command. The option
 will appear in all of the commands for which tokens are provided, the
-tokes are separated by the | character, indicating that all the
-delineated tokens are to be used.
+tokens are combined into a set with conf.Cmds, or with the Or method
+of a token, indicating that all the delineated tokens are to be used;
+There is no limit upon the number of commands.
 
 	c.Options{
 		{
@@ -29,7 +30,7 @@ delineated tokens are to be used.
 			Flag:     "s",
 			Default:  "Some string",
 			Usage:    stringUse,
-			Commands: base | cmd1 | cmd2,
+			Commands: conf.Cmds(base, cmd1, cmd2),
 		},
 	}
 
@@ -48,7 +49,7 @@ passed.
 			Flag:     "s",
 			Default:  "Some string",
 			Usage:    stringUse,
-			Commands: cmd | cmd1 | cmd2,
+			Commands: conf.Cmds(cmd, cmd1, cmd2),
 			Check: func(interface{})(interface{}, error) {
 				str := *v.(*string)
 				if len(str) == 0 {
@@ -59,6 +60,244 @@ passed.
 		},
 	}
 
+ENVIRONMENT variables may provide an options value, the environment is
+read after the Default has been set and before the command line is
+parsed, such that a flag given on the command line always has the last
+word. An option may name its own variable with the `Env:` field, else
+a name is derived for it when the Config has been given a prefix, of
+the form PREFIX_CMD_FLAG, or PREFIX_FLAG for the default set.
+
+	c.EnvPrefix("APP")
+
+	APP_GREET_S="Hello, World!" app greet
+
+CONFIG a built-in 'config' command is registered by calling Persist,
+with it the user may record option values that are then used in place
+of their defaults on every subsequent run of the program, the values
+are stored in the users configuration directory under the application
+name, $XDG_CONFIG_HOME/app/config. Options of the default set are named
+by their flag, those of a command by the command and flag joined by a
+dot.
+
+	c.Persist("app")
+
+	app config set greet.s "Hello, World!"
+	app config get greet.s
+	app config unset greet.s
+	app config list
+
+FILES may be declared from which option values are read, in order, such
+that a checked in team configuration may be followed by a per user
+override; Json, toml, yaml and ini formats are recognised by their file
+extension. Top level keys are mapped onto the option flags of the
+running command, keys within a section named after a command only apply
+to that command. Values are type checked against the option type. The
+precedence of all sources, from lowest to highest, is the Default, the
+files, the values recorded with the config command, the environment and
+finally the command line.
+
+	c.Files("/etc/app/app.toml", os.ExpandEnv("$HOME/.app.yaml"))
+
+	n = 24
+
+	[greet]
+	s = "Hello, World!"
+
+SOURCES of each value are recorded, Source returns where the value of
+an option in the running command was taken from, the default, a file
+and line, an environment variable or a command line argument; Explain
+lists every option in the running command with its value and source.
+
+	src, err := c.Source("s")
+	fmt.Print(c.Explain())
+
+TREES of commands are built by registering sub-commands beneath a
+command through its Node, the command line is walked from the default
+set for as long as its arguments name a sub-command. The Subtree token
+of a node targets the command along with all of its descendants.
+
+	remote := c.Node(c.Command("remote", helpRemote))
+	add := remote.Command("add", helpRemoteAdd)
+
+	app remote add -name origin
+
+HANDLERS may be attached to each command, Execute then composes the
+Config, calls the handler of the running command with the remaining
+arguments and maps any error to an exit code, one for a handler error,
+two for a usage or configuration error, or that of an ExitError.
+
+	c.Handle(greet, func(ctx context.Context, c *conf.Config, args []string) error {
+		s, err := c.ValueString("s")
+		if err != nil {
+			return err
+		}
+		fmt.Println(s)
+		return nil
+	})
+
+	os.Exit(c.Execute(context.Background(), opts...))
+
+VALUES are retrieved with the generic Get function, or with one of the
+typed Config.Value methods, an option of the Var type is asserted to the
+requested type from its flag.Value; Get reads the native Var types,
+IntVar et al, through their Var, such that the value returned is that of
+the variable at the time of the call, the Value methods hold no data for
+them.
+
+	n, err := conf.Get[int](c, "n")
+	s := conf.MustGet[string](c, "s")
+
+STRUCTS may be bound to a Config, Bind generates an option for each
+exported field from its `conf` tag, the struct being populated when the
+Config is composed. Nested structs either prefix the flag names of their
+fields, or when tagged as a command, assign their fields to that
+sub-command.
+
+	type Settings struct {
+		Port int    `conf:"p,default=8080,cmd=serve|run,usage=the port"`
+		Host string `conf:"host,env=APP_HOST"`
+	}
+
+	var s Settings
+	opts, err := c.Bind(&s)
+	cmd, err := c.Compose(opts...)
+
+SLICES are given either as a separated list, as repeated flags, or as a
+mix of both, the list separator being a comma unless the options
+Separator is set. Configuration files may give arrays for them.
+
+	{Type: conf.StringSlice, Flag: "tag", Default: []string{"a"}},
+
+	app -tag x,y -tag z
+
+MAPS are given as key=value pairs, either separated or as repeated
+flags, the options Duplicate policy deciding whether the first or last
+value of a repeated key is kept, or an error raised.
+
+	{Type: conf.StringMap, Flag: "label", Default: map[string]string{}},
+
+	app -label env=prod,team=core -label region=eu
+
+CHOICES restrict an option to a fixed set of values, any other value,
+from whichever source, is rejected with a suggestion of the nearest
+choice. The choices are listed in the usage output, the default marked.
+
+	{Type: conf.String, Flag: "format", Default: "text",
+		Choices: []string{"json", "text", "yaml"}},
+
+CONSTRAINTS are declared upon an option, Min and Max for the numeric
+types, Pattern for strings, MinLen MaxLen and NonEmpty for strings
+slices and maps. They are checked once the arguments are parsed, each
+violation being reported as a ConstraintError that names the flag and
+the rule, all of them being returned together as ConstraintErrors, and
+are shown in the usage output.
+
+	{Type: conf.Int, Flag: "port", Default: 8080, Min: 1, Max: 65535},
+	{Type: conf.String, Flag: "name", Default: "x", Pattern: "^[a-z]+$"},
+
+REQUIRED options must be given a value, by any source other than their
+Default, within the commands of their Required set. Compose returns a
+RequiredError listing every missing flag, and the usage output marks
+them.
+
+	{Type: conf.String, Flag: "id", Default: "", Commands: run,
+		Required: run},
+
+GROUPS declare the relationships between flags, they are checked once
+the arguments are parsed, a flag being set when it has been given a
+value by any source other than its Default. All violations are returned
+together as GroupErrors, and the groups of the running command are shown
+in its usage output.
+
+	c.Exclusive("json", "yaml", "text")
+	c.Requires("cert", "key")
+	c.AtLeastOne("id", "name")
+
+CHANGED reports whether a flag was given on the command line, as
+opposed to taking its value from its Default, a file or the environment,
+and Visit calls a function for every option that was.
+
+	if c.Changed("n") {
+		// -n was given explicitly.
+	}
+
+ERRORS raised when parsing the command line exit the program by
+default, as with the flag package; A Config that continues on error
+returns a ParseError instead, or ErrHelp when help was requested, and
+one that panics on error panics with them.
+
+	c.SetErrorHandling(conf.ContinueOnError)
+	if _, err := c.Compose(opts...); errors.Is(err, conf.ErrHelp) {
+		return nil
+	}
+
+OUTPUT is written to os.Stdout and os.Stderr unless other writers are
+set, requested help going to the first and errors, along with the usage
+that follows them, to the second.
+
+	c.SetOutput(&out, &errs)
+
+SUMMARIES of the sub-commands are listed automatically in a COMMANDS
+section of the usage output of their parent, a command having been
+given a one line description through its Node; Commands may also be
+listed under a group heading.
+
+	c.Node(one).Summary("one does all things that one should do.")
+	c.Node(add).Summary("add a remote").Group("remote")
+
+COMPLETION scripts for bash, zsh and fish are written by Completion, the
+script calls the program with the hidden __complete command, which
+writes the sub-commands, the flags of the current command or the
+choices of a flag that match the word being completed.
+
+	c.Completion("bash", os.Stdout)
+
+	app __complete remote a
+
+CANDIDATES for the value of a flag are its Choices, or those of its
+Complete function which is given the Config as composed from the words
+already typed; Without candidates the shell is directed to complete file
+names, for the string types, or as set by the options Hint.
+
+	{
+		Type:     conf.String,
+		Flag:     "branch",
+		Default:  "",
+		Commands: def,
+		Complete: func(prefix string, c *conf.Config) []string {
+			repo, _ := c.ValueString("repo")
+			return branches(repo)
+		},
+	},
+	{Type: conf.String, Flag: "out", Default: "", Hint: conf.HintDir,
+		Commands: def},
+
+MAN pages are generated one for the program and one for each of its
+commands, from the header, the usage of each command and its options
+along with their types, defaults, choices and constraints. The options
+are given as they would be to Compose, without any arguments being
+parsed, or else taken from a Config that has been composed; ProgName
+sets the program name when the pages are written by another binary.
+
+	c.ProgName("app")
+	c.Man(one, os.Stdout, opts...)
+	c.ManPages("man/man1", opts...)   // app.1, app-one.1, app-two.1
+
+REFERENCE documentation is written as markdown or html, a section for
+each command with its usage and a table of its options; The flag, type,
+default, the other commands that share the option and its usage. As
+with the man pages, the options are given or taken from the composed
+Config.
+
+	c.Reference("markdown", f, opts...)
+
+DIAGRAMS of the command tree are written in plantuml or dot, each command
+with the options that belong to it alone, those shared by several
+commands being drawn apart and linked to each of them; The options are
+again given or taken from the composed Config.
+
+	c.Diagram("dot", os.Stdout, opts...)
+
 The following is an example of the conf package in use:
 
 package main
@@ -76,13 +315,18 @@ var (
 	two = c.Command("two", helpTwo)
 )
 
+func init() {
+	c.Node(one).Summary("one does all things that one should do.")
+	c.Node(two).Summary("two, despite appearances is second to none.")
+}
+
 var opts = []conf.Option{
 	{
 		Type:     conf.Int,
 		Flag:     "n",
 		Default:  12,
 		Usage:    intUse,
-		Commands: def | one | two,
+		Commands: conf.Cmds(def, one, two),
 		Check: func(v interface{}) (interface{}, error) {
 			i := *v.(*int)
 			if i != 12 {
@@ -96,7 +340,7 @@ var opts = []conf.Option{
 		Flag:     "s",
 		Default:  "Some thing in the way she smiles",
 		Usage:    stringUse,
-		Commands: def | one | two,
+		Commands: conf.Cmds(def, one, two),
 		Check: func(v interface{}) (interface{}, error) {
 			s := *v.(*string)
 			if len(s) == 0 {
@@ -110,14 +354,14 @@ var opts = []conf.Option{
 		Flag:     "i",
 		Default:  16,
 		Usage:    "the i is the none of all the ints",
-		Commands: def | one | two,
+		Commands: conf.Cmds(def, one, two),
 	},
 	{
 		Type:     conf.Int,
 		Flag:     "v",
 		Default:  0,
 		Usage:    "Oh! The overall chattiness of it all",
-		Commands: def | one | two,
+		Commands: conf.Cmds(def, one, two),
 	},
 }
 
@@ -153,14 +397,6 @@ EXAMPLE
 // ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 
 var helpDef = `
-MODES
-        conf [mode] -[flag]
-
-	one     one does all things that one should do.
-
-	two     two, despite appearances is second to none, achieving
-		things in no less than a second.
-
 	Further details of the use of each mode can be found by running
 	the following command.
 